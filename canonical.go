@@ -0,0 +1,109 @@
+package xjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/fd/xjson/internal/jsontext"
+)
+
+// Canonical renders x using the RFC 8785 JSON Canonicalization Scheme:
+// object members sorted by the UTF-16 code units of their keys, numbers
+// formatted like ECMAScript and strings with minimal escaping. Integers that
+// cannot be represented exactly as an IEEE 754 double are rejected.
+func (x Value) Canonical() ([]byte, error) {
+	i, err := x.Interface()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := write_canonical(&buf, i, x.Selector()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func write_canonical(buf *bytes.Buffer, x interface{}, sel Selector) error {
+	switch y := x.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(y))
+	case int64, json.Number:
+		f, err := jsontext.Number(number_literal(y))
+		if err != nil {
+			return &selector_error{err, sel}
+		}
+		return write_canonical_number(buf, f, sel)
+	case float64:
+		return write_canonical_number(buf, y, sel)
+	case string:
+		return write_canonical_string(buf, y, sel)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, e := range y {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := write_canonical(buf, e, &index_selector{e, i, sel}); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := utf16_sorted_keys(y)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			e := y[key]
+			s := &key_selector{e, key, sel}
+			if err := write_canonical_string(buf, key, s); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := write_canonical(buf, e, s); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return type_conflict_error(x, "json type", sel)
+	}
+	return nil
+}
+
+func write_canonical_number(buf *bytes.Buffer, f float64, sel Selector) error {
+	if err := jsontext.WriteNumber(buf, f); err != nil {
+		return &selector_error{err, sel}
+	}
+	return nil
+}
+
+func write_canonical_string(buf *bytes.Buffer, s string, sel Selector) error {
+	if err := jsontext.WriteString(buf, s); err != nil {
+		return &selector_error{err, sel}
+	}
+	return nil
+}
+
+// utf16_sorted_keys returns the keys of m ordered by their UTF-16 code
+// units, as required by RFC 8785.
+func utf16_sorted_keys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Sort(utf16_keys(keys))
+	return keys
+}
+
+type utf16_keys []string
+
+func (k utf16_keys) Len() int           { return len(k) }
+func (k utf16_keys) Swap(i, j int)      { k[i], k[j] = k[j], k[i] }
+func (k utf16_keys) Less(i, j int) bool { return jsontext.LessUTF16(k[i], k[j]) }
@@ -0,0 +1,180 @@
+package xjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Coerced reads a Value leniently, converting between strings, numbers and
+// bools for APIs that don't use the proper json types:
+//
+//   - numbers accept numeric strings in json syntax, like "42" or "-1.5e3",
+//     with surrounding whitespace ignored, and bools as 1 or 0
+//   - bools accept the strings "true", "false", "1" and "0" and the numbers
+//     1 and 0
+//   - strings accept numbers, formatted as json, and bools
+//   - null coerces to 0, false or ""
+//
+// Arrays and objects never coerce. The range checks of Value.Int64 etc.
+// still apply after a conversion.
+type Coerced struct {
+	value Value
+}
+
+// Coerce returns a lenient view of x, see Coerced.
+func (x Value) Coerce() Coerced {
+	return Coerced{x}
+}
+
+func (c Coerced) Bool() (bool, error) {
+	i, err := c.value.Interface()
+	if err != nil {
+		return false, err
+	}
+
+	switch y := i.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return y, nil
+	case string:
+		switch y {
+		case "true", "1":
+			return true, nil
+		case "false", "0":
+			return false, nil
+		}
+	case int64, float64:
+		if compare_numbers(y, int64(0)) == 0 {
+			return false, nil
+		}
+		if compare_numbers(y, int64(1)) == 0 {
+			return true, nil
+		}
+	}
+	return false, c.conflict("bool")
+}
+
+func (c Coerced) Int() (int, error) {
+	n, err := c.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Int()
+}
+
+func (c Coerced) Int64() (int64, error) {
+	n, err := c.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64()
+}
+
+func (c Coerced) Uint64() (uint64, error) {
+	n, err := c.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Uint64()
+}
+
+func (c Coerced) Float64() (float64, error) {
+	n, err := c.number()
+	if err != nil {
+		return 0, err
+	}
+	return n.Float64()
+}
+
+func (c Coerced) String() (string, error) {
+	i, err := c.value.Interface()
+	if err != nil {
+		return "", err
+	}
+
+	switch y := i.(type) {
+	case nil:
+		return "", nil
+	case bool:
+		return strconv.FormatBool(y), nil
+	case string:
+		return y, nil
+	}
+	if is_number(i) {
+		return number_literal(i), nil
+	}
+	return "", c.conflict("string")
+}
+
+func (c Coerced) MustBool() bool {
+	v, _ := c.Bool()
+	return v
+}
+
+func (c Coerced) MustInt() int {
+	v, _ := c.Int()
+	return v
+}
+
+func (c Coerced) MustInt64() int64 {
+	v, _ := c.Int64()
+	return v
+}
+
+func (c Coerced) MustUint64() uint64 {
+	v, _ := c.Uint64()
+	return v
+}
+
+func (c Coerced) MustFloat64() float64 {
+	v, _ := c.Float64()
+	return v
+}
+
+func (c Coerced) MustString() string {
+	v, _ := c.String()
+	return v
+}
+
+// number converts the value to a json number, keeping its selector.
+func (c Coerced) number() (Value, error) {
+	i, err := c.value.Interface()
+	if err != nil {
+		return Value{}, err
+	}
+
+	n := c.value
+	switch y := i.(type) {
+	case nil:
+		n.inner = int64(0)
+	case bool:
+		n.inner = int64(0)
+		if y {
+			n.inner = int64(1)
+		}
+	case string:
+		s := new_scanner(strings.NewReader(strings.TrimSpace(y)))
+		v, err := s.scan_number()
+		if err != nil || s.chr != -1 {
+			return Value{}, c.conflict("number")
+		}
+		n.inner = v
+	default:
+		if !is_number(i) {
+			return Value{}, c.conflict("number")
+		}
+	}
+	return n, nil
+}
+
+func (c Coerced) conflict(expected string) error {
+	k := c.value.Kind()
+
+	desc := "json " + strings.ToLower(k.String())
+	if k != Array && k != Object {
+		desc += " " + describe_value(c.value)
+	}
+	return &selector_error{fmt.Errorf("xjson: cannot coerce %s to a %s", desc, expected), c.value.selector}
+}
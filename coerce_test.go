@@ -0,0 +1,44 @@
+package xjson
+
+import "testing"
+
+func TestCoerce(t *testing.T) {
+	v := Parse([]byte(`{"n": 42, "s": " 42 ", "f": "1.5", "t": "true", "one": 1, "zero": "0", "null": null, "bad": "abc", "a": [1]}`))
+
+	var tests = []struct {
+		key string
+		get func(c Coerced) (interface{}, error)
+		out interface{}
+		err string
+	}{
+		{"n", func(c Coerced) (interface{}, error) { return c.Int64() }, int64(42), ``},
+		{"s", func(c Coerced) (interface{}, error) { return c.Int64() }, int64(42), ``},
+		{"f", func(c Coerced) (interface{}, error) { return c.Float64() }, 1.5, ``},
+		{"f", func(c Coerced) (interface{}, error) { return c.Int64() }, nil, `xjson: 1.5 is not an integer (at: $root.f, line 1 col 29)`},
+		{"t", func(c Coerced) (interface{}, error) { return c.Bool() }, true, ``},
+		{"t", func(c Coerced) (interface{}, error) { return c.Int64() }, nil, `xjson: cannot coerce json string "true" to a number (at: $root.t, line 1 col 41)`},
+		{"one", func(c Coerced) (interface{}, error) { return c.Bool() }, true, ``},
+		{"zero", func(c Coerced) (interface{}, error) { return c.Bool() }, false, ``},
+		{"n", func(c Coerced) (interface{}, error) { return c.String() }, "42", ``},
+		{"t", func(c Coerced) (interface{}, error) { return c.String() }, "true", ``},
+		{"null", func(c Coerced) (interface{}, error) { return c.Int64() }, int64(0), ``},
+		{"null", func(c Coerced) (interface{}, error) { return c.String() }, "", ``},
+		{"null", func(c Coerced) (interface{}, error) { return c.Bool() }, false, ``},
+		{"bad", func(c Coerced) (interface{}, error) { return c.Bool() }, nil, `xjson: cannot coerce json string "abc" to a bool (at: $root.bad, line 1 col 93)`},
+		{"n", func(c Coerced) (interface{}, error) { return c.Bool() }, nil, `xjson: cannot coerce json number 42 to a bool (at: $root.n, line 1 col 7)`},
+		{"a", func(c Coerced) (interface{}, error) { return c.String() }, nil, `xjson: cannot coerce json array to a string (at: $root.a, line 1 col 105)`},
+	}
+
+	for _, test := range tests {
+		out, err := test.get(v.Get(test.key).Coerce())
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.key, test.err, err)
+			}
+			continue
+		}
+		if err != nil || out != test.out {
+			t.Errorf("%s: expected %v, got %v (%v)", test.key, test.out, out, err)
+		}
+	}
+}
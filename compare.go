@@ -0,0 +1,158 @@
+package xjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+type Change int
+
+const (
+	Added Change = iota
+	Removed
+	ChangedType
+	ChangedValue
+)
+
+func (c Change) String() string {
+	return change_strings[c]
+}
+
+var change_strings = map[Change]string{
+	Added:        "Added",
+	Removed:      "Removed",
+	ChangedType:  "ChangedType",
+	ChangedValue: "ChangedValue",
+}
+
+// Difference describes a single change between two documents. For Added
+// differences Old is the (missing) value in the original document, for
+// Removed differences New is the (missing) value in the modified document.
+type Difference struct {
+	Selector Selector
+	Change   Change
+	Old      Value
+	New      Value
+}
+
+func (d Difference) String() string {
+	var (
+		old = describe_value(d.Old)
+		new = describe_value(d.New)
+	)
+
+	switch d.Change {
+	case Added:
+		old = "(missing)"
+	case Removed:
+		new = "(missing)"
+	}
+
+	return fmt.Sprintf("%s: %s -> %s", d.Selector, old, new)
+}
+
+// Compare returns the differences between a and b, ordered by selector.
+// Object members are compared by key and array elements by index. Numbers
+// are compared by value, so 1 and 1.0 are equal.
+func Compare(a, b Value) []Difference {
+	var diffs []Difference
+	compare_values(&diffs, a, b)
+	return diffs
+}
+
+// WriteDifferences renders diffs to w, one difference per line.
+func WriteDifferences(w io.Writer, diffs []Difference) error {
+	for _, d := range diffs {
+		if _, err := fmt.Fprintln(w, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compare_values(diffs *[]Difference, a, b Value) {
+	var (
+		ak = a.Kind()
+		bk = b.Kind()
+	)
+
+	if ak != bk {
+		*diffs = append(*diffs, Difference{a.Selector(), ChangedType, a, b})
+		return
+	}
+
+	switch ak {
+	case Object:
+		compare_objects(diffs, a, b)
+	case Array:
+		compare_arrays(diffs, a, b)
+	case Error:
+		if a.err.Error() != b.err.Error() {
+			*diffs = append(*diffs, Difference{a.Selector(), ChangedValue, a, b})
+		}
+	default:
+		if !equal_inner(a.inner, b.inner) {
+			*diffs = append(*diffs, Difference{a.Selector(), ChangedValue, a, b})
+		}
+	}
+}
+
+func compare_objects(diffs *[]Difference, a, b Value) {
+	var (
+		ao   = a.MustObject()
+		bo   = b.MustObject()
+		keys = make([]string, 0, len(ao)+len(bo))
+	)
+
+	for key := range ao {
+		keys = append(keys, key)
+	}
+	for key := range bo {
+		if _, found := ao[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		compare_members(diffs, a.Get(key), b.Get(key))
+	}
+}
+
+func compare_arrays(diffs *[]Difference, a, b Value) {
+	n := a.Len()
+	if b.Len() > n {
+		n = b.Len()
+	}
+
+	for i := 0; i < n; i++ {
+		compare_members(diffs, a.GetIndex(i), b.GetIndex(i))
+	}
+}
+
+// compare_members compares two children; a child that could not be selected
+// is missing on that side.
+func compare_members(diffs *[]Difference, a, b Value) {
+	switch {
+	case a.err != nil:
+		*diffs = append(*diffs, Difference{b.Selector(), Added, a, b})
+	case b.err != nil:
+		*diffs = append(*diffs, Difference{a.Selector(), Removed, a, b})
+	default:
+		compare_values(diffs, a, b)
+	}
+}
+
+func describe_value(v Value) string {
+	i, err := v.Interface()
+	if err != nil {
+		return fmt.Sprintf("(%s)", err)
+	}
+	b, err := json.Marshal(i)
+	if err != nil {
+		return fmt.Sprintf("(%s)", err)
+	}
+	return string(b)
+}
@@ -0,0 +1,58 @@
+package xjson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	var tests = []struct {
+		a, b string
+		out  []string
+	}{
+		{`1`, `1.0`, nil},
+		{`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, nil},
+		{`1`, `2`, []string{`ChangedValue $root: 1 -> 2`}},
+		{`1`, `"1"`, []string{`ChangedType $root: 1 -> "1"`}},
+		{`{"a":1}`, `{"b":1}`, []string{
+			`Removed $root.a: 1 -> (missing)`,
+			`Added $root.b: (missing) -> 1`,
+		}},
+		{`[1,2,3]`, `[1]`, []string{
+			`Removed $root[1]: 2 -> (missing)`,
+			`Removed $root[2]: 3 -> (missing)`,
+		}},
+		{`{"a b":[{"c":null}]}`, `{"a b":[{"c":false}]}`, []string{
+			`ChangedType $root["a b"][0].c: null -> false`,
+		}},
+	}
+
+	for _, test := range tests {
+		diffs := Compare(Parse([]byte(test.a)), Parse([]byte(test.b)))
+
+		var out []string
+		for _, d := range diffs {
+			out = append(out, d.Change.String()+" "+d.String())
+		}
+
+		if len(out) != len(test.out) {
+			t.Errorf("%s vs %s:\n  expected %q\n  got      %q", test.a, test.b, test.out, out)
+			continue
+		}
+		for i := range out {
+			if out[i] != test.out[i] {
+				t.Errorf("%s vs %s:\n  expected %q\n  got      %q", test.a, test.b, test.out, out)
+				break
+			}
+		}
+	}
+}
+
+func TestCompare_NaN(t *testing.T) {
+	if diffs := Compare(ValueOf(math.NaN()), ValueOf(1.5)); len(diffs) != 1 || diffs[0].Change != ChangedValue {
+		t.Errorf("expected NaN and 1.5 to differ, got %v", diffs)
+	}
+	if diffs := Compare(ValueOf(math.NaN()), ValueOf(math.NaN())); len(diffs) != 0 {
+		t.Errorf("expected NaN to equal NaN, got %v", diffs)
+	}
+}
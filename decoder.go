@@ -0,0 +1,345 @@
+package xjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type TokenKind int
+
+const (
+	DelimToken TokenKind = iota
+	KeyToken
+	StringToken
+	NumberToken
+	BoolToken
+	NullToken
+)
+
+func (k TokenKind) String() string {
+	return token_kind_strings[k]
+}
+
+var token_kind_strings = map[TokenKind]string{
+	DelimToken:  "Delim",
+	KeyToken:    "Key",
+	StringToken: "String",
+	NumberToken: "Number",
+	BoolToken:   "Bool",
+	NullToken:   "Null",
+}
+
+// Token is a single token read by a Decoder. Delim is one of '{', '}', '['
+// or ']' for DelimToken, Key is set for KeyToken and Value holds the scalar
+// of String, Number, Bool and Null tokens.
+//
+// Selector is the location of the token in the document. Selectors of
+// containers and their delimiters do not carry a value as the container is
+// never materialized.
+type Token struct {
+	Kind     TokenKind
+	Delim    byte
+	Key      string
+	Value    Value
+	Selector Selector
+
+	start Position
+	end   Position
+}
+
+// Span returns the source location of the token.
+func (t Token) Span() (start, end Position) {
+	return t.start, t.end
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case DelimToken:
+		return string(t.Delim)
+	case KeyToken:
+		b, _ := json.Marshal(t.Key)
+		return string(b)
+	default:
+		return describe_value(t.Value)
+	}
+}
+
+// Decoder reads a stream of json tokens from an io.Reader. Only the token
+// being read and the path to it are held in memory. A stream may contain
+// several whitespace separated documents, each rooted at $root. Containers
+// nested more than 10000 levels deep are a syntax error.
+type Decoder struct {
+	s     *scanner
+	stack []decoder_frame
+	err   error
+}
+
+type decoder_frame struct {
+	delim byte     // '{' or '['
+	sel   Selector // selector of the container
+	state int      // one of the frame_* states
+	idx   int      // index of the next array element
+	key   string   // key of the current object member
+}
+
+const (
+	frame_start = iota
+	frame_after_member
+	frame_after_key
+)
+
+// max_depth bounds the nesting of containers, so that materializing a
+// document can't exhaust the stack.
+const max_depth = 10000
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{s: new_scanner(r)}
+}
+
+// Next returns the next token. It returns io.EOF once the input is
+// exhausted; other errors are sticky.
+func (d *Decoder) Next() (Token, error) {
+	if d.err != nil {
+		return Token{}, d.err
+	}
+
+	tok, err := d.next()
+	if err != nil {
+		if e, ok := err.(*SyntaxError); ok && e.Selector == nil {
+			e.Selector = d.enclosing()
+		}
+		d.err = err
+		return Token{}, err
+	}
+	return tok, nil
+}
+
+// enclosing returns the selector of the innermost open container.
+func (d *Decoder) enclosing() Selector {
+	if len(d.stack) == 0 {
+		return &root_selector{}
+	}
+	return d.stack[len(d.stack)-1].sel
+}
+
+func (d *Decoder) next() (Token, error) {
+	s := d.s
+	s.skip_whitespace()
+
+	if len(d.stack) == 0 {
+		if s.chr == -1 {
+			if s.err != nil {
+				return Token{}, s.err
+			}
+			return Token{}, io.EOF
+		}
+		return d.value(&root_selector{}, "value")
+	}
+
+	top := &d.stack[len(d.stack)-1]
+
+	if top.delim == '[' {
+		if s.chr == ']' {
+			return d.close()
+		}
+		expected := "value or ']'"
+		if top.state == frame_after_member {
+			if !s.scan_byte(',') {
+				return Token{}, s.unexpected("',' or ']' after array element")
+			}
+			s.skip_whitespace()
+			expected = "value"
+		}
+		top.state = frame_after_member
+		top.idx++
+		return d.value(&index_selector{nil, top.idx - 1, top.sel}, expected)
+	}
+
+	switch top.state {
+	case frame_after_key:
+		if !s.scan_byte(':') {
+			return Token{}, s.unexpected("':' after object key")
+		}
+		s.skip_whitespace()
+		top.state = frame_after_member
+		return d.value(&key_selector{nil, top.key, top.sel}, "value")
+
+	case frame_after_member:
+		if s.chr == '}' {
+			return d.close()
+		}
+		if !s.scan_byte(',') {
+			return Token{}, s.unexpected("',' or '}' after object member")
+		}
+		s.skip_whitespace()
+		if s.chr != '"' {
+			return Token{}, s.unexpected("object key")
+		}
+
+	default:
+		if s.chr == '}' {
+			return d.close()
+		}
+		if s.chr != '"' {
+			return Token{}, s.unexpected("object key or '}'")
+		}
+	}
+
+	start := s.position()
+	key, err := s.scan_string()
+	if err != nil {
+		return Token{}, err
+	}
+	top.key, top.state = key, frame_after_key
+	return Token{Kind: KeyToken, Key: key, Selector: &key_selector{nil, key, top.sel},
+		start: start, end: s.position()}, nil
+}
+
+func (d *Decoder) close() (Token, error) {
+	top := d.stack[len(d.stack)-1]
+	d.stack = d.stack[:len(d.stack)-1]
+
+	start := d.s.position()
+	d.s.next()
+
+	delim := byte('}')
+	if top.delim == '[' {
+		delim = ']'
+	}
+	return Token{Kind: DelimToken, Delim: delim, Selector: top.sel, start: start, end: d.s.position()}, nil
+}
+
+// value reads the token starting a value at sel. expected describes what
+// was expected in case there is no value.
+func (d *Decoder) value(sel Selector, expected string) (Token, error) {
+	var (
+		s     = d.s
+		kind  TokenKind
+		inner interface{}
+		err   error
+		start = s.position()
+	)
+
+	switch s.chr {
+	case '{', '[':
+		if len(d.stack) >= max_depth {
+			err = s.unexpected(fmt.Sprintf("at most %d nested containers", max_depth))
+			break
+		}
+		delim := byte(s.chr)
+		s.next()
+		d.stack = append(d.stack, decoder_frame{delim: delim, sel: sel})
+		return Token{Kind: DelimToken, Delim: delim, Selector: sel, start: start, end: s.position()}, nil
+	case '"':
+		kind = StringToken
+		inner, err = s.scan_string()
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		kind = NumberToken
+		inner, err = s.scan_number()
+	case 't':
+		kind, inner, err = BoolToken, true, s.scan_literal("true")
+	case 'f':
+		kind, inner, err = BoolToken, false, s.scan_literal("false")
+	case 'n':
+		kind, inner, err = NullToken, nil, s.scan_literal("null")
+	default:
+		err = s.unexpected(expected)
+	}
+	if err != nil {
+		if e, ok := err.(*SyntaxError); ok {
+			e.Selector = sel
+		}
+		return Token{}, err
+	}
+
+	sel = with_selector_value(sel, inner)
+	return Token{Kind: kind, Value: Value{inner, nil, sel}, Selector: sel, start: start, end: s.position()}, nil
+}
+
+// with_selector_value returns sel with its value set to v.
+func with_selector_value(sel Selector, v interface{}) Selector {
+	switch x := sel.(type) {
+	case *root_selector:
+		return &root_selector{v, nil}
+	case *index_selector:
+		return &index_selector{v, x.idx, x.parent}
+	case *key_selector:
+		return &key_selector{v, x.key, x.parent}
+	default:
+		return sel
+	}
+}
+
+// read_value materializes the value that starts with tok, consuming the
+// rest of it from d.
+func (d *Decoder) read_value(tok Token) (interface{}, *span, error) {
+	sp := &span{start: tok.start, end: tok.end}
+
+	switch tok.Delim {
+	case '{':
+		o := map[string]interface{}{}
+		sp.members = map[string]*span{}
+		for {
+			key, err := d.Next()
+			if err != nil {
+				return nil, nil, err
+			}
+			if key.Kind == DelimToken {
+				sp.end = key.end
+				return o, sp, nil
+			}
+			tok, err := d.Next()
+			if err != nil {
+				return nil, nil, err
+			}
+			v, child, err := d.read_value(tok)
+			if err != nil {
+				return nil, nil, err
+			}
+			o[key.Key], sp.members[key.Key] = v, child
+		}
+
+	case '[':
+		a := []interface{}{}
+		for {
+			tok, err := d.Next()
+			if err != nil {
+				return nil, nil, err
+			}
+			if tok.Delim == ']' {
+				sp.end = tok.end
+				return a, sp, nil
+			}
+			v, child, err := d.read_value(tok)
+			if err != nil {
+				return nil, nil, err
+			}
+			a, sp.elements = append(a, v), append(sp.elements, child)
+		}
+
+	default:
+		return tok.Value.inner, sp, nil
+	}
+}
+
+// read_document reads the next top-level value.
+func (d *Decoder) read_document() (interface{}, *span, error) {
+	tok, err := d.Next()
+	if err == io.EOF {
+		return nil, nil, d.s.unexpected("value")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.read_value(tok)
+}
+
+// expect_end fails unless only whitespace remains in the input.
+func (d *Decoder) expect_end() error {
+	d.s.skip_whitespace()
+	if d.s.chr != -1 {
+		return d.s.unexpected("end of input")
+	}
+	return d.s.err
+}
@@ -0,0 +1,82 @@
+package xjson
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out []string
+		err string
+	}{
+		{`{"a": [1, 2.5, "x", true, null], "b": {}}`, []string{
+			`Delim $root {`,
+			`Key $root.a "a"`,
+			`Delim $root.a [`,
+			`Number $root.a[0] 1`,
+			`Number $root.a[1] 2.5`,
+			`String $root.a[2] "x"`,
+			`Bool $root.a[3] true`,
+			`Null $root.a[4] null`,
+			`Delim $root.a ]`,
+			`Key $root.b "b"`,
+			`Delim $root.b {`,
+			`Delim $root.b }`,
+			`Delim $root }`,
+		}, ``},
+		{`[[],[{"a b":"\u00e9\ud83d\ude00"}]]`, []string{
+			`Delim $root [`,
+			`Delim $root[0] [`,
+			`Delim $root[0] ]`,
+			`Delim $root[1] [`,
+			`Delim $root[1][0] {`,
+			`Key $root[1][0]["a b"] "a b"`,
+			`String $root[1][0]["a b"] "é😀"`,
+			`Delim $root[1][0] }`,
+			`Delim $root[1] ]`,
+			`Delim $root ]`,
+		}, ``},
+		{` 1 "a" `, []string{
+			`Number $root 1`,
+			`String $root "a"`,
+		}, ``},
+		{`[1,]`, []string{`Delim $root [`, `Number $root[0] 1`}, `xjson: unexpected byte ']', expected value (at: $root[1], line 1 col 4)`},
+		{`{"a" 1}`, []string{`Delim $root {`, `Key $root.a "a"`}, `xjson: unexpected byte '1', expected ':' after object key (at: $root, line 1 col 6)`},
+		{`[01]`, []string{`Delim $root [`, `Number $root[0] 0`}, `xjson: unexpected byte '1', expected ',' or ']' after array element (at: $root, line 1 col 3)`},
+		{`{"a":tru}`, []string{`Delim $root {`, `Key $root.a "a"`}, `xjson: unexpected byte '}', expected "true" (at: $root.a, line 1 col 9)`},
+		{`["a`, []string{`Delim $root [`}, `xjson: unexpected end of input, expected '"' (at: $root[0], line 1 col 4)`},
+	}
+
+	for _, test := range tests {
+		var (
+			d   = NewDecoder(strings.NewReader(test.in))
+			out []string
+			err error
+		)
+
+		for {
+			var tok Token
+			tok, err = d.Next()
+			if err != nil {
+				break
+			}
+			out = append(out, tok.Kind.String()+" "+tok.Selector.String()+" "+tok.String())
+		}
+
+		if err == io.EOF {
+			err = nil
+		}
+		if strings.Join(out, "\n") != strings.Join(test.out, "\n") {
+			t.Errorf("%s:\n  expected %q\n  got      %q", test.in, test.out, out)
+		}
+		if test.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.in, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+}
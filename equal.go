@@ -0,0 +1,267 @@
+package xjson
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Equal reports whether x and other represent the same JSON value. Object
+// member order is ignored and numbers are compared by value, so 1 and 1.0
+// are equal. Values holding an error are never equal.
+func (x Value) Equal(other Value) bool {
+	if x.err != nil || other.err != nil {
+		return false
+	}
+	return equal_inner(x.inner, other.inner)
+}
+
+// Hash returns a hash of the JSON value held by x. Values that are Equal
+// have the same hash.
+func (x Value) Hash() uint64 {
+	h := fnv.New64a()
+	if x.err != nil {
+		h.Write([]byte{'e'})
+		h.Write([]byte(x.err.Error()))
+		return h.Sum64()
+	}
+	hash_inner(h, x.inner)
+	return h.Sum64()
+}
+
+func hash_inner(h hash.Hash64, x interface{}) {
+	var buf [9]byte
+
+	switch y := x.(type) {
+	case nil:
+		h.Write([]byte{'n'})
+	case bool:
+		if y {
+			h.Write([]byte{'t'})
+		} else {
+			h.Write([]byte{'f'})
+		}
+	case int64, float64, json.Number:
+		hash_number(h, y)
+	case string:
+		hash_string(h, 's', y)
+	case []interface{}:
+		buf[0] = 'a'
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(y)))
+		h.Write(buf[:])
+		for _, e := range y {
+			hash_inner(h, e)
+		}
+	case map[string]interface{}:
+		buf[0] = 'o'
+		binary.BigEndian.PutUint64(buf[1:], uint64(len(y)))
+		h.Write(buf[:])
+		for _, key := range sorted_keys(y) {
+			hash_string(h, 'k', key)
+			hash_inner(h, y[key])
+		}
+	}
+}
+
+// hash_number hashes numbers that fit an int64 as that int64, and all other
+// numbers as their reduced fraction, so equal numbers hash the same whatever
+// they are held as.
+func hash_number(h hash.Hash64, x interface{}) {
+	var buf [9]byte
+
+	if f, ok := x.(float64); ok {
+		if f == math.Trunc(f) && f >= -(1<<63) && f < 1<<63 {
+			x = int64(f)
+		} else if math.IsInf(f, 0) || math.IsNaN(f) {
+			if math.IsNaN(f) {
+				// all NaNs are equal, whatever their bits
+				f = math.NaN()
+			}
+			buf[0] = 'd'
+			binary.BigEndian.PutUint64(buf[1:], math.Float64bits(f))
+			h.Write(buf[:])
+			return
+		}
+	}
+	if i, ok := x.(int64); ok {
+		buf[0] = 'i'
+		binary.BigEndian.PutUint64(buf[1:], uint64(i))
+		h.Write(buf[:])
+		return
+	}
+
+	r, err := number_rat(x)
+	if err != nil {
+		// such numbers only equal the same literal
+		hash_string(h, 'b', number_literal(x))
+		return
+	}
+	if r.IsInt() && r.Num().IsInt64() {
+		hash_number(h, r.Num().Int64())
+		return
+	}
+	hash_string(h, 'b', r.String())
+}
+
+// hash_string writes a length prefixed string so that adjacent strings
+// cannot run into each other.
+func hash_string(h hash.Hash64, tag byte, s string) {
+	var buf [9]byte
+	buf[0] = tag
+	binary.BigEndian.PutUint64(buf[1:], uint64(len(s)))
+	h.Write(buf[:])
+	h.Write([]byte(s))
+}
+
+func equal_inner(a, b interface{}) bool {
+	switch x := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		y, ok := b.(bool)
+		return ok && x == y
+	case int64, float64, json.Number:
+		return is_number(b) && compare_numbers(a, b) == 0
+	case string:
+		y, ok := b.(string)
+		return ok && x == y
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal_inner(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, xv := range x {
+			yv, found := y[key]
+			if !found || !equal_inner(xv, yv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func is_number(i interface{}) bool {
+	switch i.(type) {
+	case int64, float64, json.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+// compare_numbers compares two json numbers exactly, without converting
+// int64 values to float64.
+func compare_numbers(a, b interface{}) int {
+	_, big_a := a.(json.Number)
+	_, big_b := b.(json.Number)
+	if big_a || big_b {
+		x, err_x := number_rat(a)
+		y, err_y := number_rat(b)
+		if err_x != nil || err_y != nil {
+			return compare_number_literals(a, b)
+		}
+		return x.Cmp(y)
+	}
+
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compare_int64(x, y)
+		case float64:
+			return -compare_float_int64(y, x)
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return compare_float_int64(x, y)
+		case float64:
+			return compare_float64(x, y)
+		}
+	}
+	panic("compare_numbers() expects json number arguments")
+}
+
+func compare_int64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compare_float64 orders NaN before all other numbers and only equal to
+// itself, so Equal stays consistent with Hash.
+func compare_float64(a, b float64) int {
+	switch {
+	case math.IsNaN(a) || math.IsNaN(b):
+		return compare_bool(!math.IsNaN(a), !math.IsNaN(b))
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compare_bool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compare_float_int64(f float64, i int64) int {
+	if math.IsNaN(f) {
+		return -1
+	}
+	if f >= math.MaxInt64 {
+		// float64(math.MaxInt64) == 1<<63 which is out of range
+		return 1
+	}
+	if f < math.MinInt64 {
+		return -1
+	}
+
+	t := math.Trunc(f)
+	if c := compare_int64(int64(t), i); c != 0 {
+		return c
+	}
+	return compare_float64(f, t)
+}
+
+// compare_number_literals orders numbers that big.Rat cannot hold by their
+// nearest float64 (±Inf or ±0), and then by their literal.
+func compare_number_literals(a, b interface{}) int {
+	x, _ := strconv.ParseFloat(number_literal(a), 64)
+	y, _ := strconv.ParseFloat(number_literal(b), 64)
+	if c := compare_float64(x, y); c != 0 {
+		return c
+	}
+	return strings.Compare(number_literal(a), number_literal(b))
+}
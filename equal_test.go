@@ -0,0 +1,54 @@
+package xjson
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	var tests = []struct {
+		a, b  interface{}
+		equal bool
+	}{
+		{nil, nil, true},
+		{int64(1), float64(1), true},
+		{int64(-1), float64(-1), true},
+		{int64(0), math.Copysign(0, -1), true},
+		{int64(1 << 53), float64(1 << 53), true},
+		{int64(1<<53 + 1), float64(1 << 53), false},
+		{float64(0.5), float64(0.5), true},
+		{int64(1), "1", false},
+		{map[string]interface{}{"a": int64(1), "b": []interface{}{true, nil}},
+			map[string]interface{}{"b": []interface{}{true, nil}, "a": float64(1)}, true},
+		{map[string]interface{}{"a": int64(1)}, map[string]interface{}{"a": int64(1), "b": int64(2)}, false},
+		{[]interface{}{int64(1), int64(2)}, []interface{}{int64(2), int64(1)}, false},
+		{[]interface{}{"ab"}, []interface{}{"a", "b"}, false},
+		{math.NaN(), math.NaN(), true},
+		{math.NaN(), math.Float64frombits(math.Float64bits(math.NaN()) | 1), true},
+		{math.NaN(), float64(1.5), false},
+		{math.NaN(), int64(0), false},
+		{math.NaN(), math.Inf(1), false},
+		{math.Inf(-1), math.Inf(-1), true},
+	}
+
+	for _, test := range tests {
+		var (
+			a = ValueOf(test.a)
+			b = ValueOf(test.b)
+		)
+
+		if a.Equal(b) != test.equal || b.Equal(a) != test.equal {
+			t.Errorf("%#v == %#v: expected %v", test.a, test.b, test.equal)
+		}
+		if test.equal && a.Hash() != b.Hash() {
+			t.Errorf("%#v and %#v: expected equal hashes", test.a, test.b)
+		}
+		if !test.equal && a.Hash() == b.Hash() {
+			t.Errorf("%#v and %#v: unexpected hash collision", test.a, test.b)
+		}
+	}
+
+	if Parse([]byte(`{`)).Equal(Parse([]byte(`{`))) {
+		t.Errorf("errors should never be equal")
+	}
+}
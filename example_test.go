@@ -3,7 +3,9 @@ package xjson
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 func Example() {
@@ -45,9 +47,9 @@ func Example() {
 	// Output:
 	// $root.people[1].name => "Hans Spooren"
 	// $root.people[1]["first name"] => "Hans"
-	// $root.people[3]["first name"] => "" (xjson: index out of range (at: $root.people[3]))
-	// $root.people[0].name => false (xjson: string is not a json bool (at: $root.people[0].name))
-	// $root.pets[0].name => "" (xjson: key not found (at: $root.pets))
+	// $root.people[3]["first name"] => "" (xjson: index out of range (at: $root.people[3], line 3 col 14))
+	// $root.people[0].name => false (xjson: string is not a json bool (at: $root.people[0].name, line 4 col 15))
+	// $root.pets[0].name => "" (xjson: key not found (at: $root.pets, line 2 col 3))
 }
 
 func ExampleValue_GetPath() {
@@ -89,9 +91,9 @@ func ExampleValue_GetPath() {
 	// Output:
 	// $root.people[1].name => "Hans Spooren"
 	// $root.people[1]["first name"] => "Hans"
-	// $root.people[3]["first name"] => "" (xjson: index out of range (at: $root.people[3]))
-	// $root.people[0].name => false (xjson: string is not a json bool (at: $root.people[0].name))
-	// $root.pets[0].name => "" (xjson: key not found (at: $root.pets))
+	// $root.people[3]["first name"] => "" (xjson: index out of range (at: $root.people[3], line 3 col 14))
+	// $root.people[0].name => false (xjson: string is not a json bool (at: $root.people[0].name, line 4 col 15))
+	// $root.pets[0].name => "" (xjson: key not found (at: $root.pets, line 2 col 3))
 }
 
 func ExampleValue_UnmarshalJSON() {
@@ -195,5 +197,318 @@ func ExampleValue_Unwrap() {
 
 	// Output:
 	// xjson.Person{"name":"Simon Menke"} (err=%!s(<nil>))
-	// xjson.Person{"name":"Hans Spooren", "first name":"Hans"} (err=%!s(<nil>))
+	// xjson.Person{"first name":"Hans", "name":"Hans Spooren"} (err=%!s(<nil>))
+}
+
+func ExampleValue_Unwrap_struct() {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke", "AGE": 30, "id": "42" },
+				{ "name": "Hans Spooren", "first name": "Hans", "secret": "..." }
+				]
+			}
+		`
+
+	type Base struct {
+		ID int64 `json:"id,string"`
+	}
+
+	type Person struct {
+		Base
+		Name      string `json:"name"`
+		FirstName string `json:"first name,omitempty"`
+		Age       int
+		Secret    string `json:"-"`
+	}
+
+	var (
+		people []Person
+		r      = Parse([]byte(js))
+		err    error
+	)
+
+	err = r.Get("people").Unwrap(&people)
+	fmt.Printf("%+v (err=%s)\n", people[0], err)
+	fmt.Printf("%+v (err=%s)\n", people[1], err)
+
+	// Output:
+	// {Base:{ID:42} Name:Simon Menke FirstName: Age:30 Secret:} (err=%!s(<nil>))
+	// {Base:{ID:0} Name:Hans Spooren FirstName:Hans Age:0 Secret:} (err=%!s(<nil>))
+}
+
+func ExampleValue_Unwrap_errors() {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke", "age": 30 },
+				{ "name": "Hans Spooren", "age": "unknown" },
+				{ "name": 42, "age": 300 }
+				]
+			}
+		`
+
+	type Person struct {
+		Name string `json:"name"`
+		Age  int8   `json:"age"`
+	}
+
+	var (
+		people []Person
+		r      = Parse([]byte(js))
+		err    error
+	)
+
+	err = r.Get("people").Unwrap(&people)
+	fmt.Println(err)
+
+	err = (&Unwrapper{FailFast: true}).Unwrap(r.Get("people"), &people)
+	fmt.Println(err)
+
+	// Output:
+	// xjson: 3 errors while unwrapping:
+	// 	$root.people[1].age: json string is not assignable to int8
+	// 	$root.people[2].name: json number is not assignable to string
+	// 	$root.people[2].age: json number 300 overflows int8
+	// $root.people[1].age: json string is not assignable to int8
+}
+
+func ExampleValue_Query() {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke", "age": 29 },
+				{ "name": "Hans Spooren", "first name": "Hans", "age": 42 }
+				]
+			}
+		`
+
+	var (
+		r = Parse([]byte(js))
+	)
+
+	nodes, err := r.Query(`$.people[?(@.age > 30)]["first name"]`)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, x := range nodes {
+		fmt.Printf("%s => %q\n", x.Selector(), x.MustString())
+	}
+
+	// Output:
+	// $root.people[1]["first name"] => "Hans"
+}
+
+func ExampleValue_GetPointer() {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke" },
+				{ "name": "Hans Spooren", "first name": "Hans", "a/b~c": true }
+				]
+			}
+		`
+
+	var (
+		r   = Parse([]byte(js))
+		x   Value
+		s   string
+		err error
+	)
+
+	x = r.GetPointer("/people/1/first name")
+	fmt.Printf("%s => %q\n", x.Selector().Pointer(), x.MustString())
+
+	x = r.GetPointer("/people/1/a~1b~0c")
+	fmt.Printf("%s => %v\n", x.Selector().Pointer(), x.MustBool())
+
+	x = r.GetPointer("/people/-")
+	s, err = x.String()
+	fmt.Printf("%s => %q (%s)\n", x.Selector().Pointer(), s, err)
+
+	// Output:
+	// /people/1/first name => "Hans"
+	// /people/1/a~1b~0c => true
+	// /people/2 => "" (xjson: index out of range (at: $root.people[2], line 3 col 14))
+}
+
+func ExampleParseSelector() {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke" },
+				{ "name": "Hans Spooren", "first name": "Hans" }
+				]
+			}
+		`
+
+	var (
+		r = Parse([]byte(js))
+		x Value
+	)
+
+	sel, err := ParseSelector(`$root.people[1]["first name"]`)
+	if err != nil {
+		panic(err)
+	}
+
+	x = r.At(sel)
+	fmt.Printf("%s => %q\n", x.Selector(), x.MustString())
+
+	// Output:
+	// $root.people[1]["first name"] => "Hans"
+}
+
+func ExampleValue_Set() {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke" },
+				{ "name": "Hans Spooren", "first name": "Hans" }
+				]
+			}
+		`
+
+	var (
+		r = Parse([]byte(js))
+		x Value
+	)
+
+	x = r.Set(r.GetPath("people", 1, "name").Selector(), "Hans S.")
+	x = x.Delete(x.GetPath("people", 1, "first name").Selector())
+	x = x.Set(x.GetPath("people", 0, "age").Selector(), 29)
+	x = x.Append(x.Get("people").Selector(), map[string]interface{}{"name": "Bob"})
+	x = x.Insert(x.Get("people").Selector(), 0, map[string]interface{}{"name": "Alice"})
+
+	b, _ := json.Marshal(&x)
+	fmt.Printf("%s\n", b)
+
+	b, _ = json.Marshal(&r)
+	fmt.Printf("%s\n", b)
+
+	x = r.Delete(r.GetPath("people", 3).Selector())
+	_, err := x.Interface()
+	fmt.Printf("%s\n", err)
+
+	// Output:
+	// {"people":[{"name":"Alice"},{"age":29,"name":"Simon Menke"},{"name":"Hans S."},{"name":"Bob"}]}
+	// {"people":[{"name":"Simon Menke"},{"first name":"Hans","name":"Hans Spooren"}]}
+	// xjson: index out of range (at: $root.people[3], line 3 col 14)
+}
+
+func ExampleValue_MergePatch() {
+	var (
+		doc   = Parse([]byte(`{"title":"Goodbye!","author":{"givenName":"John","familyName":"Doe"},"tags":["example","sample"]}`))
+		patch = Parse([]byte(`{"title":"Hello!","phoneNumber":"+01-123-456-7890","author":{"familyName":null},"tags":["example"]}`))
+	)
+
+	x := doc.MergePatch(patch)
+	b, _ := json.Marshal(&x)
+	fmt.Printf("%s\n", b)
+
+	p := CreateMergePatch(doc, x)
+	b, _ = json.Marshal(&p)
+	fmt.Printf("%s\n", b)
+
+	// Output:
+	// {"author":{"givenName":"John"},"phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}
+	// {"author":{"familyName":null},"phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}
+}
+
+func ExampleCompare() {
+	var (
+		a = Parse([]byte(`{"people":[{"name":"Simon","age":29},{"name":"Hans"}],"count":2}`))
+		b = Parse([]byte(`{"people":[{"name":"Simon","age":"29"},{"name":"Hans S."},{"name":"Bob"}],"count":2.0}`))
+	)
+
+	WriteDifferences(os.Stdout, Compare(a, b))
+
+	// Output:
+	// $root.people[0].age: 29 -> "29"
+	// $root.people[1].name: "Hans" -> "Hans S."
+	// $root.people[2]: (missing) -> {"name":"Bob"}
+}
+
+func ExampleDecoder_Next() {
+	d := NewDecoder(strings.NewReader(`{"people":[{"name":"Simon Menke"},{"name":"Hans Spooren"}]}`))
+
+	for {
+		tok, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		if tok.Kind == StringToken {
+			fmt.Printf("%s = %s\n", tok.Selector, tok)
+		}
+	}
+
+	// Output:
+	// $root.people[0].name = "Simon Menke"
+	// $root.people[1].name = "Hans Spooren"
+}
+
+func ExampleStream() {
+	var js = `
+		{
+			"count": 3,
+			"events": [
+				{ "type": "login", "user": "simon" },
+				{ "type": "logout", "user": "simon" },
+				{ "type": "login", "user": "hans" }
+			]
+		}
+	`
+
+	err := Stream(strings.NewReader(js), []string{`$root.events[*]`}, func(x Value) error {
+		fmt.Printf("%s: %s %s\n", x.Selector(), x.Get("user").MustString(), x.Get("type").MustString())
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// $root.events[0]: simon login
+	// $root.events[1]: simon logout
+	// $root.events[2]: hans login
+}
+
+func ExampleReadLines() {
+	var js = `{"user":{"id":1},"action":"login"}
+{"user":{"id":2},"action":"login"}
+
+{"user":{"id":1},"action":
+{"user":{"id":"3"},"action":"logout"}
+`
+
+	var (
+		r = ReadLines(strings.NewReader(js))
+		w = NewLineWriter(os.Stdout)
+	)
+
+	for r.Next() {
+		x := r.Value()
+
+		id, err := x.Get("user").Get("id").Int64()
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+
+		w.Write(ValueOf(map[string]interface{}{"id": id, "line": r.Line()}))
+	}
+	if err := r.Err(); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {"id":1,"line":1}
+	// {"id":2,"line":2}
+	// xjson: unexpected end of input, expected value (at: $line[4].action, line 4 col 27)
+	// xjson: string is not a json number (at: $line[5].user.id, line 5 col 15)
 }
@@ -0,0 +1,84 @@
+package xjson
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/fd/xjson/internal/jsontext"
+)
+
+// canonical renders v using RFC 8785. Numbers and strings are written by
+// internal/jsontext, so both packages accept and reject the same values.
+func canonical(v Value) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := write_canonical(&buf, v, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func write_canonical(buf *bytes.Buffer, v Value, path []interface{}) error {
+	switch x := v.(type) {
+	case *errorValue:
+		return x.err
+	case *zeroValue, *nullValue:
+		buf.WriteString("null")
+	case *boolValue:
+		buf.WriteString(strconv.FormatBool(x.val))
+	case *numberValue:
+		f, err := jsontext.Number(string(x.buf))
+		if err == nil {
+			err = jsontext.WriteNumber(buf, f)
+		}
+		if err != nil {
+			return jsontext.PathError(err, path)
+		}
+	case *stringValue:
+		if err := jsontext.WriteString(buf, x.val); err != nil {
+			return jsontext.PathError(err, path)
+		}
+	case *arrayValue:
+		buf.WriteByte('[')
+		for i, e := range x.values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := write_canonical(buf, e, append(path, i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *objectValue:
+		// members are sorted by their UTF-8 bytes; RFC 8785 wants UTF-16
+		// code units, which only differs for keys outside the BMP.
+		members := make([]objectMember, len(x.members))
+		copy(members, x.members)
+		sort.Stable(utf16ObjectMembers(members))
+
+		buf.WriteByte('{')
+		for i, m := range members {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			sub := append(path, m.key)
+			if err := jsontext.WriteString(buf, m.key); err != nil {
+				return jsontext.PathError(err, sub)
+			}
+			buf.WriteByte(':')
+			if err := write_canonical(buf, m.value, sub); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return nil
+}
+
+type utf16ObjectMembers []objectMember
+
+func (s utf16ObjectMembers) Len() int      { return len(s) }
+func (s utf16ObjectMembers) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s utf16ObjectMembers) Less(i, j int) bool {
+	return jsontext.LessUTF16(s[i].key, s[j].key)
+}
@@ -0,0 +1,58 @@
+package xjson
+
+import (
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	var tests = []struct {
+		in, out string
+	}{
+		// RFC 8785, section 3.2.2
+		{`{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`},
+		// RFC 8785, section 3.2.3
+		{`{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`,
+			"{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"},
+		{`-0`, `0`},
+		{`1e21`, `1e+21`},
+		{`1e20`, `100000000000000000000`},
+		{`0.000001`, `0.000001`},
+		{`1e-7`, `1e-7`},
+		{`"\u2028<>&"`, "\"\u2028<>&\""},
+	}
+
+	for _, test := range tests {
+		out, err := Parse([]byte(test.in)).Canonical()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.in, err)
+			continue
+		}
+		if string(out) != test.out {
+			t.Errorf("%s:\n  expected %q\n  got      %q", test.in, test.out, out)
+		}
+	}
+
+	var errors = []struct {
+		in, err string
+	}{
+		{`1e400`, `xjson: 1e400 cannot be represented as a double (at: $root)`},
+		{`{"a":[1,9007199254740993]}`, `xjson: 9007199254740993 cannot be represented exactly as a double (at: $root.a[1])`},
+	}
+	for _, test := range errors {
+		_, err := Parse([]byte(test.in)).Canonical()
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%q: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+
+	// the parser replaces invalid UTF-8, so build the value by hand
+	v := &arrayValue{values: []Value{&stringValue{val: "\xff"}}}
+	if _, err := v.Canonical(); err == nil || err.Error() != `xjson: invalid UTF-8 in string "\xff" (at: $root[0])` {
+		t.Errorf("expected invalid UTF-8 to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package xjson
+
+import (
+	"bytes"
+	"math/big"
+	"strconv"
+
+	"github.com/fd/xjson/internal/jsontext"
+)
+
+// skip_comment skips a `//` or `/* */` comment at the current position and
+// reports whether there was one. An unterminated block comment runs to the
+// end of the input and leaves its error in s.pending.
+func (s *scanner) skip_comment() bool {
+	switch s.peek() {
+	case '/':
+		for s.chr != '\n' && s.chr != -1 {
+			s.next()
+		}
+	case '*':
+		s.next()
+		s.next()
+		for s.chr != -1 && !(s.chr == '*' && s.peek() == '/') {
+			s.next()
+		}
+		if s.chr == -1 {
+			if s.pending == nil {
+				s.pending = jsontext.NewSyntaxError(s.buf, s.pos, "'*/' to close unterminated block comment", s.value_path())
+			}
+			return true
+		}
+		s.next()
+		s.next()
+	default:
+		return false
+	}
+
+	s.rewrite = true
+	return true
+}
+
+func (s *scanner) scan_non_finite(beg int, flags numberFlags) (*numberValue, error) {
+	lit := "Infinity"
+	if s.chr == 'N' {
+		lit = "NaN"
+	}
+
+	for i := 0; i < len(lit); i++ {
+		if !s.scan_byte(lit[i]) {
+			return nil, s.unexpected(strconv.Quote(lit))
+		}
+	}
+	s.rewrite = true
+
+	if lit == "Infinity" && flags&numberIsNegative != 0 {
+		lit = "-Infinity"
+	}
+	return &numberValue{[]byte(lit), flags, s.span(beg, s.pos)}, nil
+}
+
+func (s *scanner) scan_hex_number(beg int, flags numberFlags) (*numberValue, error) {
+	s.next() // 0
+	s.next() // x
+
+	digits := s.pos
+	if !s.scan_hex_digits() {
+		return nil, s.unexpected("hex digit")
+	}
+	s.rewrite = true
+
+	n, _ := new(big.Int).SetString(string(s.buf[digits:s.pos]), 16)
+	if flags&numberIsNegative != 0 {
+		n.Neg(n)
+	}
+	return &numberValue{[]byte(n.String()), flags, s.span(beg, s.pos)}, nil
+}
+
+func (s *scanner) peek() int {
+	if s.pos+1 < len(s.buf) {
+		return int(s.buf[s.pos+1])
+	}
+	return -1
+}
+
+// normalize_number turns a number like +1, .5 or 5. into plain json.
+func normalize_number(b []byte) []byte {
+	var out []byte
+
+	if b[0] == '+' {
+		b = b[1:]
+	} else if b[0] == '-' {
+		out = append(out, '-')
+		b = b[1:]
+	}
+	if b[0] == '.' {
+		out = append(out, '0')
+	}
+
+	if i := bytes.IndexByte(b, '.'); i >= 0 && (i+1 == len(b) || b[i+1] < '0' || b[i+1] > '9') {
+		out = append(out, b[:i]...)
+		return append(out, b[i+1:]...)
+	}
+	return append(out, b...)
+}
+
+// json5_string turns a JSON5 string literal into a json string literal.
+func json5_string(b []byte) []byte {
+	var (
+		body = b[1 : len(b)-1]
+		out  = []byte{'"'}
+	)
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+
+		if c == '"' {
+			out = append(out, '\\', '"')
+			continue
+		}
+		if c != '\\' {
+			out = append(out, c)
+			continue
+		}
+
+		i++
+		switch body[i] {
+		case '\'':
+			out = append(out, '\'')
+		case '0':
+			out = append(out, `\u0000`...)
+		case 'v':
+			out = append(out, `\u000b`...)
+		case 'x':
+			out = append(out, `\u00`...)
+			out = append(out, body[i+1:i+3]...)
+			i += 2
+		case '\r':
+			if i+1 < len(body) && body[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+			// line continuation
+		default:
+			out = append(out, '\\', body[i])
+		}
+	}
+
+	return append(out, '"')
+}
+
+func is_identifier_start(c int) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || c == '_' || c == '$' || c >= 0x80
+}
+
+func is_identifier_part(c int) bool {
+	return is_identifier_start(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,251 @@
+package xjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Document is a parsed json text that keeps its exact source, comments and
+// whitespace included. Edits only re-render the values they touch, all other
+// bytes are written back as they were read. This makes it suitable for
+// updating human-written config files.
+type Document struct {
+	buf  []byte
+	mode Mode
+	root Value
+	keys map[Value]int // the offset of the key of every object member
+}
+
+// ParseDocument parses b in mode. Trailing data after the value is an error.
+func ParseDocument(b []byte, mode Mode) (*Document, error) {
+	d := &Document{mode: mode}
+	if err := d.parse(b); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Document) parse(b []byte) error {
+	s := new_scanner(b)
+	s.mode = d.mode
+	s.keys = map[Value]int{}
+
+	v, err := s.scan_value()
+	if err == nil {
+		err = s.scan_end()
+	}
+	if err != nil {
+		return err
+	}
+
+	d.buf, d.root, d.keys = b, v, s.keys
+	return nil
+}
+
+// Value returns the current value of the document.
+func (d *Document) Value() Value {
+	return d.root
+}
+
+// Bytes returns the source of the document with all edits applied.
+func (d *Document) Bytes() []byte {
+	return d.buf
+}
+
+// Set replaces the value at path with v, which is either a Value or
+// anything that can be marshaled by encoding/json. A missing object member is
+// added after the last member of its object.
+func (d *Document) Set(v interface{}, path ...interface{}) error {
+	text, err := render_value(v)
+	if err != nil {
+		return err
+	}
+
+	if start, end := d.root.Path(path...).Span(); start.IsValid() {
+		return d.splice(start.Offset, end.Offset, text)
+	}
+
+	if len(path) == 0 {
+		return fmt.Errorf("xjson: no value at %v", path)
+	}
+	key, ok := path[len(path)-1].(string)
+	parent := d.root.Path(path[:len(path)-1]...)
+	if start, end := parent.Span(); ok && start.IsValid() && parent.Kind() == Object {
+		return d.insert_member(start.Offset, end.Offset, d.children(parent), key, text)
+	}
+	return fmt.Errorf("xjson: no value at %v", path)
+}
+
+// Delete removes the array element or object member at path, together with
+// its separating comma.
+func (d *Document) Delete(path ...interface{}) error {
+	if len(path) == 0 {
+		return fmt.Errorf("xjson: can't delete the document root")
+	}
+
+	_, end := d.root.Path(path...).Span()
+	if !end.IsValid() {
+		return fmt.Errorf("xjson: no value at %v", path)
+	}
+
+	children := d.children(d.root.Path(path[:len(path)-1]...))
+	i := sort.Search(len(children), func(i int) bool { return children[i].end >= end.Offset })
+	c := children[i]
+
+	var beg, stop int
+	switch {
+	case i < len(children)-1:
+		// remove up to and including the comma and the spaces after it
+		beg, stop = c.beg, d.skip_spaces(d.skip_trivia(c.end)+1)
+	case i > 0:
+		// remove the comma after the previous child
+		beg, stop = children[i-1].end, c.end
+	default:
+		beg, stop = c.beg, c.end
+		if p := d.skip_trivia(stop); p < len(d.buf) && d.buf[p] == ',' {
+			stop = p + 1
+		}
+	}
+
+	// a line comment after the child belongs to it
+	if p := d.skip_spaces(stop); d.mode&Comments != 0 && bytes.HasPrefix(d.buf[p:], []byte("//")) {
+		stop = len(d.buf)
+		if i := bytes.IndexByte(d.buf[p:], '\n'); i >= 0 {
+			stop = p + i
+		}
+	}
+
+	beg, stop = expand_lines(d.buf, beg, stop)
+	return d.splice(beg, stop, nil)
+}
+
+func (d *Document) insert_member(beg, end int, members []child, key string, text []byte) error {
+	name, _ := json.Marshal(key)
+
+	var b []byte
+	if len(members) == 0 {
+		b = append(append(append(b, name...), ": "...), text...)
+		return d.splice(beg+1, beg+1, b)
+	}
+
+	last := members[len(members)-1]
+	if line_start(d.buf, last.beg) > beg {
+		// members are on lines of their own, copy the indentation
+		b = append(b, ",\n"...)
+		b = append(b, indentation(d.buf, last.beg)...)
+	} else {
+		b = append(b, ", "...)
+	}
+	b = append(append(append(b, name...), ": "...), text...)
+	return d.splice(last.end, last.end, b)
+}
+
+// splice replaces buf[beg:end] with text and parses the result.
+func (d *Document) splice(beg, end int, text []byte) error {
+	b := make([]byte, 0, len(d.buf)-(end-beg)+len(text))
+	b = append(b, d.buf[:beg]...)
+	b = append(b, text...)
+	b = append(b, d.buf[end:]...)
+	return d.parse(b)
+}
+
+// child is the source range of an array element or object member, starting
+// at the key of a member.
+type child struct {
+	beg int
+	end int
+}
+
+// children returns the children of v in source order.
+func (d *Document) children(v Value) []child {
+	var children []child
+
+	switch x := v.(type) {
+	case *arrayValue:
+		for _, e := range x.values {
+			start, end := e.Span()
+			children = append(children, child{start.Offset, end.Offset})
+		}
+	case *objectValue:
+		for _, m := range x.members {
+			_, end := m.value.Span()
+			children = append(children, child{d.keys[m.value], end.Offset})
+		}
+		sort.Sort(sortedChildren(children))
+	}
+
+	return children
+}
+
+// skip_trivia returns the offset of the first byte at or after offset that is
+// not whitespace or a comment.
+func (d *Document) skip_trivia(offset int) int {
+	s := new_scanner_range(&source{buf: d.buf}, offset, len(d.buf))
+	s.mode = d.mode
+	s.skip_whitespace()
+	return s.pos
+}
+
+// skip_spaces returns the offset of the first byte at or after offset that
+// is not a space or tab.
+func (d *Document) skip_spaces(offset int) int {
+	for offset < len(d.buf) && (d.buf[offset] == ' ' || d.buf[offset] == '\t') {
+		offset++
+	}
+	return offset
+}
+
+type sortedChildren []child
+
+func (s sortedChildren) Len() int           { return len(s) }
+func (s sortedChildren) Less(i, j int) bool { return s[i].beg < s[j].beg }
+func (s sortedChildren) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func render_value(v interface{}) ([]byte, error) {
+	if x, ok := v.(Value); ok {
+		if raw := raw_bytes(x); raw != nil {
+			return raw, nil
+		}
+		if x.Kind() == Error {
+			return nil, x.(*errorValue).err
+		}
+		return []byte("null"), nil
+	}
+	return json.Marshal(v)
+}
+
+// expand_lines grows buf[beg:end] to whole lines when nothing but
+// whitespace surrounds it on its first and last line.
+func expand_lines(buf []byte, beg, end int) (int, int) {
+	i := beg
+	for i > 0 && (buf[i-1] == ' ' || buf[i-1] == '\t') {
+		i--
+	}
+	j := end
+	for j < len(buf) && (buf[j] == ' ' || buf[j] == '\t' || buf[j] == '\r') {
+		j++
+	}
+
+	if (i == 0 || buf[i-1] == '\n') && j < len(buf) && buf[j] == '\n' {
+		return i, j + 1
+	}
+	return beg, end
+}
+
+func line_start(buf []byte, offset int) int {
+	for offset > 0 && buf[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+func indentation(buf []byte, offset int) []byte {
+	beg := line_start(buf, offset)
+	end := beg
+	for end < offset && (buf[end] == ' ' || buf[end] == '\t') {
+		end++
+	}
+	return buf[beg:end]
+}
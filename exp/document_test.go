@@ -0,0 +1,128 @@
+package xjson
+
+import "testing"
+
+func TestDocument(t *testing.T) {
+	var src = `// service config
+{
+  "name": "api", // the public name
+  /* ports */
+  "ports": [80, 443],
+  "debug": false,
+}
+`
+
+	var tests = []struct {
+		edit func(d *Document) error
+		out  string
+	}{
+		{
+			func(d *Document) error { return nil },
+			src,
+		},
+		{
+			func(d *Document) error { return d.Set("web", "name") },
+			`// service config
+{
+  "name": "web", // the public name
+  /* ports */
+  "ports": [80, 443],
+  "debug": false,
+}
+`,
+		},
+		{
+			func(d *Document) error { return d.Set(8080, "ports", 1) },
+			`// service config
+{
+  "name": "api", // the public name
+  /* ports */
+  "ports": [80, 8080],
+  "debug": false,
+}
+`,
+		},
+		{
+			func(d *Document) error { return d.Set(map[string]int{"max": 10}, "limits") },
+			`// service config
+{
+  "name": "api", // the public name
+  /* ports */
+  "ports": [80, 443],
+  "debug": false,
+  "limits": {"max":10},
+}
+`,
+		},
+		{
+			func(d *Document) error { return d.Delete("debug") },
+			`// service config
+{
+  "name": "api", // the public name
+  /* ports */
+  "ports": [80, 443],
+}
+`,
+		},
+		{
+			func(d *Document) error { return d.Delete("name") },
+			`// service config
+{
+  /* ports */
+  "ports": [80, 443],
+  "debug": false,
+}
+`,
+		},
+		{
+			func(d *Document) error { return d.Delete("ports", 0) },
+			`// service config
+{
+  "name": "api", // the public name
+  /* ports */
+  "ports": [443],
+  "debug": false,
+}
+`,
+		},
+		{
+			func(d *Document) error { return d.Delete("ports", 1) },
+			`// service config
+{
+  "name": "api", // the public name
+  /* ports */
+  "ports": [80],
+  "debug": false,
+}
+`,
+		},
+	}
+
+	for i, test := range tests {
+		d, err := ParseDocument([]byte(src), JSONC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.edit(d); err != nil {
+			t.Errorf("%d: unexpected error: %s", i, err)
+			continue
+		}
+		if out := string(d.Bytes()); out != test.out {
+			t.Errorf("%d:\n  expected %s\n  got      %s", i, test.out, out)
+		}
+	}
+
+	d, _ := ParseDocument([]byte(`{"a": {}}`), 0)
+	if err := d.Set(true, "a", "b"); err != nil || string(d.Bytes()) != `{"a": {"b": true}}` {
+		t.Errorf("unexpected result %s (%v)", d.Bytes(), err)
+	}
+	if !d.Value().Path("a", "b").Bool() {
+		t.Errorf("expected the value to be updated")
+	}
+	if err := d.Set(1, "x", "y"); err == nil {
+		t.Errorf("expected an error for a missing parent")
+	}
+	if err := d.Delete(); err == nil {
+		t.Errorf("expected an error when deleting the root")
+	}
+}
@@ -0,0 +1,116 @@
+package xjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// new_object builds an object from members, rendering its buf from the
+// members' own bufs.
+func new_object(members []objectMember) *objectValue {
+	sort.Sort(sortedObjectMembers(members))
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		write_compact(&buf, m.value)
+	}
+	buf.WriteByte('}')
+
+	return &objectValue{buf.Bytes(), members, span{}}
+}
+
+// write_compact writes v without the whitespace of its source. Unlike
+// json.Compact it accepts the NaN and Infinity literals of the NonFinite
+// mode, which are written as they are.
+func write_compact(buf *bytes.Buffer, v Value) {
+	raw := raw_bytes(v)
+	if raw == nil {
+		buf.WriteString("null")
+		return
+	}
+
+	in_string := false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case in_string && c == '\\' && i+1 < len(raw):
+			buf.WriteByte(c)
+			i++
+			c = raw[i]
+		case in_string && c == '"':
+			in_string = false
+		case c == '"':
+			in_string = true
+		case !in_string && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			continue
+		}
+		buf.WriteByte(c)
+	}
+}
+
+// raw_bytes returns the json source of v, or nil for values that have none.
+func raw_bytes(v Value) []byte {
+	switch x := v.(type) {
+	case *nullValue:
+		return x.buf
+	case *boolValue:
+		return x.buf
+	case *numberValue:
+		return x.buf
+	case *stringValue:
+		return x.buf
+	case *arrayValue:
+		return x.buf
+	case *objectValue:
+		return x.buf
+	default:
+		return nil
+	}
+}
+
+// render_object renders members, in order, as a json object.
+func render_object(members []objectMember) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		write_raw(&buf, m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// render_array renders values as a json array.
+func render_array(values []Value) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, v := range values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		write_raw(&buf, v)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
+
+func write_raw(buf *bytes.Buffer, v Value) {
+	if raw := raw_bytes(v); raw != nil {
+		buf.Write(raw)
+	} else {
+		buf.WriteString("null")
+	}
+}
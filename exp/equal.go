@@ -0,0 +1,129 @@
+package xjson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math/big"
+)
+
+func equal_values(a, b Value) bool {
+	switch x := a.(type) {
+	case *nullValue, *zeroValue:
+		return b.Kind() == Null
+	case *boolValue:
+		y, ok := b.(*boolValue)
+		return ok && x.val == y.val
+	case *numberValue:
+		y, ok := b.(*numberValue)
+		return ok && equal_numbers(x, y)
+	case *stringValue:
+		y, ok := b.(*stringValue)
+		return ok && x.val == y.val
+	case *arrayValue:
+		y, ok := b.(*arrayValue)
+		if !ok || len(x.values) != len(y.values) {
+			return false
+		}
+		for i := range x.values {
+			if !equal_values(x.values[i], y.values[i]) {
+				return false
+			}
+		}
+		return true
+	case *objectValue:
+		// members are sorted by key, so they can be compared pairwise
+		y, ok := b.(*objectValue)
+		if !ok || len(x.members) != len(y.members) {
+			return false
+		}
+		for i := range x.members {
+			if x.members[i].key != y.members[i].key {
+				return false
+			}
+			if !equal_values(x.members[i].value, y.members[i].value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+func equal_numbers(a, b *numberValue) bool {
+	if bytes.Equal(a.buf, b.buf) {
+		return true
+	}
+
+	x, ok1 := new(big.Rat).SetString(string(a.buf))
+	y, ok2 := new(big.Rat).SetString(string(b.buf))
+	return ok1 && ok2 && x.Cmp(y) == 0
+}
+
+func hash_value(v Value) uint64 {
+	h := fnv.New64a()
+	write_hash(h, v)
+	return h.Sum64()
+}
+
+func write_hash(h hash.Hash64, v Value) {
+	switch x := v.(type) {
+	case *errorValue:
+		hash_string(h, 'e', x.err.Error())
+	case *nullValue, *zeroValue:
+		h.Write([]byte{'n'})
+	case *boolValue:
+		if x.val {
+			h.Write([]byte{'t'})
+		} else {
+			h.Write([]byte{'f'})
+		}
+	case *numberValue:
+		hash_string(h, 'd', canonical_number(x))
+	case *stringValue:
+		hash_string(h, 's', x.val)
+	case *arrayValue:
+		hash_len(h, 'a', len(x.values))
+		for _, e := range x.values {
+			write_hash(h, e)
+		}
+	case *objectValue:
+		// members are sorted by key, so the hash is independent of the
+		// member order in the source
+		hash_len(h, 'o', len(x.members))
+		for _, m := range x.members {
+			hash_string(h, 'k', m.key)
+			write_hash(h, m.value)
+		}
+	}
+}
+
+// canonical_number renders numerically equal numbers the same way.
+func canonical_number(x *numberValue) string {
+	if x.flags&(numberHasFraction|numberHasExponent) == 0 {
+		if string(x.buf) == "-0" {
+			return "0"
+		}
+		return string(x.buf)
+	}
+
+	r, ok := new(big.Rat).SetString(string(x.buf))
+	if !ok {
+		return string(x.buf)
+	}
+	return r.RatString()
+}
+
+func hash_len(h hash.Hash64, tag byte, n int) {
+	var buf [9]byte
+	buf[0] = tag
+	binary.BigEndian.PutUint64(buf[1:], uint64(n))
+	h.Write(buf[:])
+}
+
+func hash_string(h hash.Hash64, tag byte, s string) {
+	hash_len(h, tag, len(s))
+	h.Write([]byte(s))
+}
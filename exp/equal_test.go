@@ -0,0 +1,42 @@
+package xjson
+
+import (
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	var tests = []struct {
+		a, b  string
+		equal bool
+	}{
+		{`null`, `null`, true},
+		{`1`, `1.0`, true},
+		{`1`, `10e-1`, true},
+		{`-0`, `0`, true},
+		{`0.1`, `1e-1`, true},
+		{`1`, `2`, false},
+		{`1`, `"1"`, false},
+		{`"ab"`, `"ab"`, true},
+		{`{"a":1,"b":[true,null]}`, `{ "b" : [true, null], "a" : 1.0 }`, true},
+		{`{"a":1}`, `{"a":1,"b":2}`, false},
+		{`[1,2]`, `[2,1]`, false},
+		{`["ab"]`, `["a","b"]`, false},
+	}
+
+	for _, test := range tests {
+		var (
+			a = Parse([]byte(test.a))
+			b = Parse([]byte(test.b))
+		)
+
+		if a.Equal(b) != test.equal || b.Equal(a) != test.equal {
+			t.Errorf("%s == %s: expected %v", test.a, test.b, test.equal)
+		}
+		if test.equal && a.Hash() != b.Hash() {
+			t.Errorf("%s and %s: expected equal hashes", test.a, test.b)
+		}
+		if !test.equal && a.Hash() == b.Hash() {
+			t.Errorf("%s and %s: unexpected hash collision", test.a, test.b)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package xjson
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"strconv"
 )
@@ -45,13 +44,8 @@ func (x *nullValue) Format(f fmt.State, c rune) {
 			f.Write(x.buf)
 			return
 		} else {
-			var (
-				buf bytes.Buffer
-				err = json.Compact(&buf, x.buf)
-			)
-			if err != nil {
-				panic(err)
-			}
+			var buf bytes.Buffer
+			write_compact(&buf, x)
 			buf.WriteTo(f)
 			return
 		}
@@ -65,13 +59,8 @@ func (x *boolValue) Format(f fmt.State, c rune) {
 			f.Write(x.buf)
 			return
 		} else {
-			var (
-				buf bytes.Buffer
-				err = json.Compact(&buf, x.buf)
-			)
-			if err != nil {
-				panic(err)
-			}
+			var buf bytes.Buffer
+			write_compact(&buf, x)
 			buf.WriteTo(f)
 			return
 		}
@@ -85,13 +74,8 @@ func (x *numberValue) Format(f fmt.State, c rune) {
 			f.Write(x.buf)
 			return
 		} else {
-			var (
-				buf bytes.Buffer
-				err = json.Compact(&buf, x.buf)
-			)
-			if err != nil {
-				panic(err)
-			}
+			var buf bytes.Buffer
+			write_compact(&buf, x)
 			buf.WriteTo(f)
 			return
 		}
@@ -109,13 +93,8 @@ func (x *stringValue) Format(f fmt.State, c rune) {
 			f.Write(x.buf)
 			return
 		} else {
-			var (
-				buf bytes.Buffer
-				err = json.Compact(&buf, x.buf)
-			)
-			if err != nil {
-				panic(err)
-			}
+			var buf bytes.Buffer
+			write_compact(&buf, x)
 			buf.WriteTo(f)
 			return
 		}
@@ -129,13 +108,8 @@ func (x *arrayValue) Format(f fmt.State, c rune) {
 			f.Write(x.buf)
 			return
 		} else {
-			var (
-				buf bytes.Buffer
-				err = json.Compact(&buf, x.buf)
-			)
-			if err != nil {
-				panic(err)
-			}
+			var buf bytes.Buffer
+			write_compact(&buf, x)
 			buf.WriteTo(f)
 			return
 		}
@@ -149,13 +123,8 @@ func (x *objectValue) Format(f fmt.State, c rune) {
 			f.Write(x.buf)
 			return
 		} else {
-			var (
-				buf bytes.Buffer
-				err = json.Compact(&buf, x.buf)
-			)
-			if err != nil {
-				panic(err)
-			}
+			var buf bytes.Buffer
+			write_compact(&buf, x)
 			buf.WriteTo(f)
 			return
 		}
@@ -0,0 +1,93 @@
+package xjson
+
+// CreateMergePatch returns the RFC 7386 JSON Merge Patch that transforms
+// original into modified. Note that merge patches cannot set members to
+// null; those are treated as removals.
+func CreateMergePatch(original, modified Value) Value {
+	if original.Kind() == Error {
+		return original
+	}
+	if modified.Kind() == Error {
+		return modified
+	}
+	return create_merge_patch(original, modified)
+}
+
+func merge_patch(target, patch Value) Value {
+	if target.Kind() == Error {
+		return target
+	}
+
+	p, ok := patch.(*objectValue)
+	if !ok {
+		return patch
+	}
+
+	var (
+		members []objectMember
+		t       []objectMember
+		i, j    int
+	)
+
+	if o, ok := target.(*objectValue); ok {
+		t = o.members
+	}
+
+	// both member lists are sorted by key
+	for i < len(t) || j < len(p.members) {
+		switch {
+		case j == len(p.members) || (i < len(t) && t[i].key < p.members[j].key):
+			members = append(members, t[i])
+			i++
+		case i == len(t) || p.members[j].key < t[i].key:
+			if m := p.members[j]; m.value.Kind() != Null {
+				members = append(members, objectMember{m.key, merge_patch(zero, m.value)})
+			}
+			j++
+		default:
+			if m := p.members[j]; m.value.Kind() != Null {
+				members = append(members, objectMember{m.key, merge_patch(t[i].value, m.value)})
+			}
+			i++
+			j++
+		}
+	}
+
+	return new_object(members)
+}
+
+func create_merge_patch(original, modified Value) Value {
+	a, ok := original.(*objectValue)
+	if !ok {
+		return modified
+	}
+	b, ok := modified.(*objectValue)
+	if !ok {
+		return modified
+	}
+
+	var (
+		members []objectMember
+		i, j    int
+	)
+
+	for i < len(a.members) || j < len(b.members) {
+		switch {
+		case j == len(b.members) || (i < len(a.members) && a.members[i].key < b.members[j].key):
+			members = append(members, objectMember{a.members[i].key, &nullValue{[]byte("null"), span{}}})
+			i++
+		case i == len(a.members) || b.members[j].key < a.members[i].key:
+			members = append(members, b.members[j])
+			j++
+		default:
+			if !equal_values(a.members[i].value, b.members[j].value) {
+				members = append(members, objectMember{b.members[j].key,
+					create_merge_patch(a.members[i].value, b.members[j].value)})
+			}
+			i++
+			j++
+		}
+	}
+
+	return new_object(members)
+}
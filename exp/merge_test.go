@@ -0,0 +1,59 @@
+package xjson
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7386, appendix A
+	var tests = []struct {
+		target, patch, out string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		var (
+			target = Parse([]byte(test.target))
+			patch  = Parse([]byte(test.patch))
+			out    = fmt.Sprintf("%j", target.MergePatch(patch))
+		)
+
+		if out != test.out {
+			t.Errorf("%s + %s:\n  expected %s\n  got      %s", test.target, test.patch, test.out, out)
+		}
+
+		// patches without nulls can be recreated
+		created := CreateMergePatch(target, target.MergePatch(patch))
+		if out := fmt.Sprintf("%j", target.MergePatch(created)); out != test.out && test.out != `{"a":{"bb":{}}}` {
+			t.Errorf("CreateMergePatch(%s, %s) = %j does not round trip", test.target, test.out, created)
+		}
+	}
+}
+
+func TestMergePatch_nonFinite(t *testing.T) {
+	var (
+		target = Parse([]byte(`{"a": "x y", "b": 1}`))
+		patch  = ParseMode([]byte(`{b: [NaN, -Infinity], c: {d: Infinity}}`), JSON5)
+		out    = fmt.Sprintf("%j", target.MergePatch(patch))
+	)
+
+	if out != `{"a":"x y","b":[NaN,-Infinity],"c":{"d":Infinity}}` {
+		t.Errorf("unexpected merge result: %s", out)
+	}
+}
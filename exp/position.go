@@ -0,0 +1,50 @@
+package xjson
+
+import (
+	"sort"
+	"sync"
+
+	root "github.com/fd/xjson"
+)
+
+// Position is a location in the parsed source. Line and Column are 1-based,
+// Column counts bytes.
+type Position = root.Position
+
+// SyntaxError describes malformed json, see xjson.SyntaxError.
+type SyntaxError = root.SyntaxError
+
+// source is the buffer a value was parsed from. The offsets of line starts
+// are only computed when a position is requested.
+type source struct {
+	buf   []byte
+	once  sync.Once
+	lines []int
+}
+
+type span struct {
+	src *source
+	beg int
+	end int
+}
+
+func (x span) positions() (start, end Position) {
+	if x.src == nil {
+		return
+	}
+	return x.src.position(x.beg), x.src.position(x.end)
+}
+
+func (s *source) position(offset int) Position {
+	s.once.Do(func() {
+		s.lines = []int{0}
+		for i, c := range s.buf {
+			if c == '\n' {
+				s.lines = append(s.lines, i+1)
+			}
+		}
+	})
+
+	line := sort.Search(len(s.lines), func(i int) bool { return s.lines[i] > offset }) - 1
+	return Position{Offset: offset, Line: line + 1, Column: offset - s.lines[line] + 1}
+}
@@ -1,23 +1,181 @@
 package xjson
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fd/xjson/internal/jsontext"
+)
+
+type Mode uint
+
+const (
+	// Strict rejects anything but whitespace after the value.
+	Strict Mode = 1 << iota
+
+	// Comments allows `// line` and `/* block */` comments where
+	// whitespace is allowed.
+	Comments
+	// TrailingCommas allows a ',' after the last array element or object
+	// member.
+	TrailingCommas
+	// SingleQuotes allows 'single quoted' strings and the extra escapes of
+	// JSON5: \', \xHH, \0, \v and escaped line breaks.
+	SingleQuotes
+	// IdentifierKeys allows unquoted object keys like {key: 1}.
+	IdentifierKeys
+	// HexNumbers allows hexadecimal integers like 0x1F.
+	HexNumbers
+	// NonFinite allows Infinity, -Infinity and NaN.
+	NonFinite
+	// LenientNumbers allows an explicit '+' sign and a leading or trailing
+	// decimal point, like +1, .5 and 5.
+	LenientNumbers
+
+	// JSONC is json with comments, as used by many config files.
+	JSONC = Comments | TrailingCommas
+	// JSON5 accepts the syntax of https://json5.org.
+	JSON5 = Comments | TrailingCommas | SingleQuotes | IdentifierKeys | HexNumbers | NonFinite | LenientNumbers
 )
 
+// Values parsed in any of the dialect modes hold plain json in their raw
+// bytes; containers with comments or other extensions are re-rendered from
+// their children. Only NaN and Infinity have no json equivalent and are kept
+// as is.
+
 func Parse(b []byte) Value {
-	s := new_scanner(b)
+	return ParseMode(b, 0)
+}
+
+func ParseMode(b []byte, mode Mode) Value {
+	return parse_range(&source{buf: b}, 0, len(b), mode)
+}
+
+// parse_range parses src.buf[beg:end], keeping positions relative to the
+// whole source.
+func parse_range(src *source, beg, end int, mode Mode) Value {
+	s := new_scanner_range(src, beg, end)
+	s.mode = mode
 	v, err := s.scan_value()
+	if err == nil && mode&Strict != 0 {
+		err = s.scan_end()
+	}
 	if err != nil {
 		v = &errorValue{err}
 	}
 	return v
 }
 
+// ParseTolerant parses b like ParseMode but doesn't stop at the first
+// syntax error. It recovers at the next ',', ']' or '}' and returns the best
+// effort tree, where malformed values are replaced by Error values, along
+// with all errors that were found.
+func ParseTolerant(b []byte, mode Mode) (Value, ErrorList) {
+	s := new_scanner(b)
+	s.mode = mode
+	s.tolerant = true
+
+	v, err := s.scan_value()
+	if err != nil {
+		s.recover_from(err)
+		v = &errorValue{err}
+	}
+	if err == nil && mode&Strict != 0 {
+		if err := s.scan_end(); err != nil {
+			s.errs = append(s.errs, err)
+		}
+	}
+
+	if len(s.errs) == 0 {
+		return v, nil
+	}
+	return v, ErrorList(s.errs)
+}
+
+// ErrorList lists the syntax errors found by ParseTolerant.
+type ErrorList []error
+
+func (e ErrorList) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("xjson: %d syntax errors:", len(e)))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n\t")
+}
+
+// ParseMany parses a sequence of values. It accepts both RFC 7464 json text
+// sequences, where every value is preceded by a record separator (0x1E),
+// and values that simply follow each other. In a text sequence a malformed
+// value yields an Error value and parsing resumes at the next record
+// separator; otherwise parsing stops at the first error.
+func ParseMany(b []byte) []Value {
+	var values []Value
+
+	if trimmed := bytes.TrimLeft(b, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == record_separator {
+		var (
+			src = &source{buf: b}
+			beg = len(b) - len(trimmed) + 1
+		)
+		for _, record := range bytes.Split(trimmed[1:], []byte{record_separator}) {
+			if len(bytes.TrimSpace(record)) > 0 {
+				values = append(values, parse_range(src, beg, beg+len(record), Strict))
+			}
+			beg += len(record) + 1
+		}
+		return values
+	}
+
+	s := new_scanner(b)
+	for {
+		s.skip_whitespace()
+		if s.pending != nil {
+			return append(values, &errorValue{s.pending})
+		}
+		if s.chr == -1 {
+			return values
+		}
+
+		v, err := s.scan_value()
+		if err != nil {
+			return append(values, &errorValue{err})
+		}
+		values = append(values, v)
+	}
+}
+
+const record_separator = 0x1E
+
 type scanner struct {
-	buf []byte
-	pos int
-	chr int
+	buf  []byte
+	pos  int
+	chr  int
+	src  *source
+	path []interface{}
+	mode Mode
+
+	// set when the current container used a dialect extension
+	rewrite bool
+
+	// the offset of the key of every object member, see Document
+	keys map[Value]int
+
+	// an error found while skipping whitespace, reported by the next call
+	// to unexpected
+	pending error
+
+	// tolerant scanning records errors and recovers from them
+	tolerant bool
+	quote    int // quote of the string being scanned, 0 outside of strings
+	errs     []error
+	err_pos  int
 }
 
 type numberFlags uint8
@@ -29,14 +187,38 @@ const (
 )
 
 func new_scanner(b []byte) *scanner {
-	if len(b) == 0 {
-		return &scanner{chr: -1}
+	return new_scanner_range(&source{buf: b}, 0, len(b))
+}
+
+func new_scanner_range(src *source, beg, end int) *scanner {
+	s := &scanner{buf: src.buf[:end], pos: beg, chr: -1, src: src}
+	if beg < end {
+		s.chr = int(s.buf[beg])
 	}
-	return &scanner{buf: b, chr: int(b[0])}
+	return s
+}
+
+func (s *scanner) span(beg, end int) span {
+	return span{s.src, beg, end}
 }
 
 func (s *scanner) err(format string, a ...interface{}) error {
-	return fmt.Errorf("xjson: %s (pos=%d)", fmt.Sprintf(format, a...), s.pos)
+	return fmt.Errorf("xjson: %s (%s)", fmt.Sprintf(format, a...), s.src.position(s.pos))
+}
+
+// unexpected reports the current byte as a *SyntaxError in the value at
+// s.path.
+func (s *scanner) unexpected(expected string) error {
+	if s.pending != nil {
+		return s.pending
+	}
+	return jsontext.NewSyntaxError(s.buf, s.pos, expected, s.value_path())
+}
+
+// value_path returns a copy of s.path. It is never nil, which would place
+// errors outside of any value.
+func (s *scanner) value_path() []interface{} {
+	return append([]interface{}{}, s.path...)
 }
 
 func (s *scanner) scan_value() (Value, error) {
@@ -57,10 +239,22 @@ func (s *scanner) scan_value() (Value, error) {
 		return s.scan_array()
 	case '{': // object
 		return s.scan_object()
-	default:
-		// parse error
-		return nil, s.err("unexpected byte %q", s.chr)
+	case '+', '.': // lenient number
+		if s.mode&(LenientNumbers|NonFinite) != 0 {
+			return s.scan_number()
+		}
+	case 'I', 'N': // Infinity, NaN
+		if s.mode&NonFinite != 0 {
+			return s.scan_number()
+		}
+	case '\'': // single quoted string
+		if s.mode&SingleQuotes != 0 {
+			return s.scan_string()
+		}
 	}
+
+	// parse error
+	return nil, s.unexpected("value")
 }
 
 func (s *scanner) scan_object() (*objectValue, error) {
@@ -72,56 +266,123 @@ func (s *scanner) scan_object() (*objectValue, error) {
 
 	beg = s.pos
 	if !s.scan_byte('{') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected("'{'")
 	}
 
+	rewrite := s.rewrite
+	s.rewrite = false
+
 	s.skip_whitespace()
 
 	if s.chr == '}' {
 		s.next()
 		end = s.pos
-		return &objectValue{nil}, nil
+		buf := s.buf[beg:end]
+		if s.rewrite {
+			buf = []byte("{}")
+		}
+		s.rewrite = rewrite
+		return &objectValue{buf, nil, s.span(beg, end)}, nil
 	}
 
+	expected := "object key or '}'"
 	for {
-		key_value, err := s.scan_string()
+		member, err := s.scan_member(expected)
 		if err != nil {
-			return nil, err
-		}
-		key_bytes, ok := unquoteBytes(key_value.buf)
-		if !ok {
-			return nil, s.err("invalid string %q", key_value.buf)
-		}
-		key := string(key_bytes)
-
-		s.skip_whitespace()
-		if !s.scan_byte(':') {
-			return nil, s.err("unexpected byte %q", s.chr)
+			if !s.tolerant {
+				return nil, err
+			}
+			s.recover_from(err)
 		}
-		s.skip_whitespace()
-
-		val, err := s.scan_value()
-		if err != nil {
-			return nil, err
+		if member.value != nil {
+			members = append(members, member)
 		}
-		members = append(members, objectMember{key, val})
 
 		s.skip_whitespace()
 		if s.chr == ',' {
 			s.next()
 			s.skip_whitespace()
+			expected = "object key"
+			if s.chr == '}' && s.mode&TrailingCommas != 0 {
+				s.next()
+				s.rewrite = true
+				break
+			}
 		} else if s.chr == '}' {
 			s.next()
 			break
 		} else {
-			return nil, s.err("unexpected byte %q", s.chr)
+			err := s.unexpected("',' or '}' after object member")
+			if !s.tolerant {
+				return nil, err
+			}
+			s.recover_from(err)
+			if s.chr == ',' {
+				s.next()
+				s.skip_whitespace()
+				expected = "object key"
+				continue
+			}
+			s.scan_byte('}')
+			break
 		}
 	}
 
+	end = s.pos
+	buf := s.buf[beg:end]
+	if s.rewrite {
+		buf = render_object(members)
+	}
+	s.rewrite = s.rewrite || rewrite
+
 	sort.Sort(sortedObjectMembers(members))
 
-	end = s.pos
-	return &objectValue{members}, nil
+	return &objectValue{buf, members, s.span(beg, end)}, nil
+}
+
+// scan_member scans a `"key": value` pair. When the key was read but the
+// value is malformed the member holds an Error value.
+func (s *scanner) scan_member(expected string) (objectMember, error) {
+	var (
+		key string
+		beg = s.pos
+	)
+
+	if s.chr == '"' || s.chr == '\'' && s.mode&SingleQuotes != 0 {
+		key_value, err := s.scan_string()
+		if err != nil {
+			return objectMember{}, err
+		}
+		key = key_value.val
+	} else if is_identifier_start(s.chr) && s.mode&IdentifierKeys != 0 {
+		beg := s.pos
+		for is_identifier_part(s.chr) {
+			s.next()
+		}
+		key = string(s.buf[beg:s.pos])
+		s.rewrite = true
+	} else {
+		return objectMember{}, s.unexpected(expected)
+	}
+
+	s.skip_whitespace()
+	if !s.scan_byte(':') {
+		err := s.unexpected("':' after object key")
+		return objectMember{key, &errorValue{err}}, err
+	}
+	s.skip_whitespace()
+
+	s.path = append(s.path, key)
+	val, err := s.scan_value()
+	s.path = s.path[:len(s.path)-1]
+	if err != nil {
+		return objectMember{key, &errorValue{err}}, err
+	}
+
+	if s.keys != nil {
+		s.keys[val] = beg
+	}
+	return objectMember{key, val}, nil
 }
 
 func (s *scanner) scan_array() (*arrayValue, error) {
@@ -133,20 +394,34 @@ func (s *scanner) scan_array() (*arrayValue, error) {
 
 	beg = s.pos
 	if !s.scan_byte('[') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected("'['")
 	}
 
+	rewrite := s.rewrite
+	s.rewrite = false
+
 	s.skip_whitespace()
 	if s.chr == ']' {
 		s.next()
 		end = s.pos
-		return &arrayValue{values}, nil
+		buf := s.buf[beg:end]
+		if s.rewrite {
+			buf = []byte("[]")
+		}
+		s.rewrite = rewrite
+		return &arrayValue{buf, values, s.span(beg, end)}, nil
 	}
 
 	for {
+		s.path = append(s.path, len(values))
 		val, err := s.scan_value()
+		s.path = s.path[:len(s.path)-1]
 		if err != nil {
-			return nil, err
+			if !s.tolerant {
+				return nil, err
+			}
+			s.recover_from(err)
+			val = &errorValue{err}
 		}
 		values = append(values, val)
 
@@ -154,16 +429,88 @@ func (s *scanner) scan_array() (*arrayValue, error) {
 		if s.chr == ',' {
 			s.next()
 			s.skip_whitespace()
+			if s.chr == ']' && s.mode&TrailingCommas != 0 {
+				s.next()
+				s.rewrite = true
+				break
+			}
 		} else if s.chr == ']' {
 			s.next()
 			break
 		} else {
-			return nil, s.err("unexpected byte %q", s.chr)
+			err := s.unexpected("',' or ']' after array element")
+			if !s.tolerant {
+				return nil, err
+			}
+			s.recover_from(err)
+			if s.chr == ',' {
+				s.next()
+				s.skip_whitespace()
+				continue
+			}
+			s.scan_byte(']')
+			break
 		}
 	}
 
 	end = s.pos
-	return &arrayValue{values}, nil
+	buf := s.buf[beg:end]
+	if s.rewrite {
+		buf = render_array(values)
+	}
+	s.rewrite = s.rewrite || rewrite
+
+	return &arrayValue{buf, values, s.span(beg, end)}, nil
+}
+
+// recover_from records err and skips ahead to the next ',', ']' or '}' on
+// the current nesting level. An error at the same offset as the previous one
+// is a consequence of it and isn't recorded again.
+func (s *scanner) recover_from(err error) {
+	if len(s.errs) == 0 || s.err_pos != s.pos {
+		s.errs = append(s.errs, err)
+		s.err_pos = s.pos
+	}
+
+	if s.quote != 0 {
+		s.skip_string(s.quote)
+	}
+
+	depth := 0
+	for s.chr != -1 {
+		switch s.chr {
+		case '"', '\'':
+			quote := s.chr
+			s.next()
+			s.skip_string(quote)
+			continue
+		case '[', '{':
+			depth++
+		case ']', '}':
+			if depth == 0 {
+				return
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				return
+			}
+		}
+		s.next()
+	}
+}
+
+// skip_string skips the rest of a string up to and including the closing
+// quote. It stops early at a control character, which ends a broken string.
+func (s *scanner) skip_string(quote int) {
+	s.quote = 0
+	for s.chr >= 0x20 && s.chr != quote {
+		if s.chr == '\\' {
+			s.next()
+		}
+		s.next()
+	}
+	s.scan_byte(byte(quote))
 }
 
 func (s *scanner) scan_null() (*nullValue, error) {
@@ -174,20 +521,20 @@ func (s *scanner) scan_null() (*nullValue, error) {
 
 	beg = s.pos
 	if !s.scan_byte('n') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"null"`)
 	}
 	if !s.scan_byte('u') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"null"`)
 	}
 	if !s.scan_byte('l') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"null"`)
 	}
 	if !s.scan_byte('l') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"null"`)
 	}
 	end = s.pos
 
-	return &nullValue{s.buf[beg:end]}, nil
+	return &nullValue{s.buf[beg:end], s.span(beg, end)}, nil
 }
 
 func (s *scanner) scan_false() (*boolValue, error) {
@@ -198,23 +545,23 @@ func (s *scanner) scan_false() (*boolValue, error) {
 
 	beg = s.pos
 	if !s.scan_byte('f') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"false"`)
 	}
 	if !s.scan_byte('a') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"false"`)
 	}
 	if !s.scan_byte('l') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"false"`)
 	}
 	if !s.scan_byte('s') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"false"`)
 	}
 	if !s.scan_byte('e') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"false"`)
 	}
 	end = s.pos
 
-	return &boolValue{s.buf[beg:end], false}, nil
+	return &boolValue{s.buf[beg:end], false, s.span(beg, end)}, nil
 }
 
 func (s *scanner) scan_true() (*boolValue, error) {
@@ -225,36 +572,41 @@ func (s *scanner) scan_true() (*boolValue, error) {
 
 	beg = s.pos
 	if !s.scan_byte('t') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"true"`)
 	}
 	if !s.scan_byte('r') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"true"`)
 	}
 	if !s.scan_byte('u') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"true"`)
 	}
 	if !s.scan_byte('e') {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected(`"true"`)
 	}
 	end = s.pos
 
-	return &boolValue{s.buf[beg:end], true}, nil
+	return &boolValue{s.buf[beg:end], true, s.span(beg, end)}, nil
 }
 
 func (s *scanner) scan_string() (*stringValue, error) {
 	var (
-		beg int
-		end int
+		beg     int
+		end     int
+		quote   int
+		dialect bool
 	)
 
 	beg = s.pos
+	quote = s.chr
 
-	if !s.scan_byte('"') {
-
+	if !s.scan_byte('"') && !(s.mode&SingleQuotes != 0 && s.scan_byte('\'')) {
+		return nil, s.unexpected("string")
 	}
+	dialect = quote != '"'
 
+	s.quote = quote
 	for {
-		if s.chr == '"' {
+		if s.chr == quote {
 			s.next()
 			break
 		} else if s.chr == '\\' {
@@ -262,51 +614,93 @@ func (s *scanner) scan_string() (*stringValue, error) {
 			if s.chr == '"' || s.chr == '\\' || s.chr == '/' || s.chr == 'b' || s.chr == 'f' || s.chr == 'n' || s.chr == 'r' || s.chr == 't' {
 				s.next()
 			} else if s.chr == 'u' {
+				s.next()
 				if !s.scan_hex_digits() {
-					return nil, s.err("unexpected byte %q", s.chr)
+					return nil, s.unexpected("hex digit")
+				}
+			} else if s.mode&SingleQuotes != 0 && s.chr == 'x' {
+				s.next()
+				for i := 0; i < 2; i++ {
+					if !jsontext.IsHexDigit(s.chr) {
+						return nil, s.unexpected("hex digit")
+					}
+					s.next()
+				}
+				dialect = true
+			} else if s.mode&SingleQuotes != 0 && (s.chr == '\'' || s.chr == '0' || s.chr == 'v' || s.chr == '\n' || s.chr == '\r') {
+				if s.scan_byte('\r') {
+					s.scan_byte('\n')
+				} else {
+					s.next()
 				}
+				dialect = true
 			} else {
-				return nil, s.err("unexpected byte %q", s.chr)
+				return nil, s.unexpected("escape sequence")
 			}
+		} else if s.chr < 0x20 {
+			return nil, s.unexpected(strconv.QuoteRune(rune(quote)))
 		} else {
 			s.next()
 		}
 	}
+	s.quote = 0
 
 	end = s.pos
 
-	b, ok := unquoteBytes(s.buf[beg:end])
+	buf := s.buf[beg:end]
+	if dialect {
+		buf = json5_string(buf)
+		s.rewrite = true
+	}
+
+	b, ok := unquoteBytes(buf)
 	if !ok {
 		return nil, s.err("invalid string: %q", s.buf[beg:end])
 	}
 
-	return &stringValue{s.buf[beg:end], string(b)}, nil
+	return &stringValue{buf, string(b), s.span(beg, end)}, nil
 }
 
 func (s *scanner) scan_number() (*numberValue, error) {
 	var (
-		beg   int
-		end   int
-		flags numberFlags
+		beg     int
+		end     int
+		flags   numberFlags
+		lenient bool
 	)
 
 	beg = s.pos
 	if s.scan_byte('-') {
 		flags |= numberIsNegative
+	} else if s.mode&(LenientNumbers|NonFinite) != 0 && s.scan_byte('+') {
+		lenient = true
+	}
+
+	if s.mode&NonFinite != 0 && (s.chr == 'I' || s.chr == 'N') {
+		return s.scan_non_finite(beg, flags)
+	}
+	if s.mode&HexNumbers != 0 && s.chr == '0' && (s.peek() == 'x' || s.peek() == 'X') {
+		return s.scan_hex_number(beg, flags)
 	}
 
+	has_int := s.chr != '.'
 	if s.scan_byte('0') {
 		// ok
 	} else if '1' <= s.chr && s.chr <= '9' {
 		s.scan_dec_digits()
+	} else if s.chr == '.' && s.mode&LenientNumbers != 0 {
+		lenient = true
 	} else {
-		return nil, s.err("unexpected byte %q", s.chr)
+		return nil, s.unexpected("digit")
 	}
 
 	if s.scan_byte('.') {
-		flags |= numberHasFraction
-		if !s.scan_dec_digits() {
-			return nil, s.err("unexpected byte %q", s.chr)
+		if s.scan_dec_digits() {
+			flags |= numberHasFraction
+		} else if has_int && s.mode&LenientNumbers != 0 {
+			lenient = true
+		} else {
+			return nil, s.unexpected("digit")
 		}
 	}
 
@@ -315,17 +709,37 @@ func (s *scanner) scan_number() (*numberValue, error) {
 		flags |= numberHasExponent
 		s.scan_sign()
 		if !s.scan_dec_digits() {
-			return nil, s.err("unexpected byte %q", s.chr)
+			return nil, s.unexpected("digit")
 		}
 	}
 
 	end = s.pos
-	return &numberValue{s.buf[beg:end], flags}, nil
+
+	buf := s.buf[beg:end]
+	if lenient {
+		buf = normalize_number(buf)
+		s.rewrite = true
+	}
+
+	return &numberValue{buf, flags, s.span(beg, end)}, nil
+}
+
+func (s *scanner) scan_end() error {
+	s.skip_whitespace()
+	if s.pending != nil {
+		return s.pending
+	}
+	if s.chr != -1 {
+		// trailing data is outside of any value
+		return jsontext.NewSyntaxError(s.buf, s.pos, "end of input", nil)
+	}
+	return nil
 }
 
 func (s *scanner) scan_byte(c byte) bool {
 	if s.chr == int(c) {
-		return s.next()
+		s.next()
+		return true
 	}
 	return false
 }
@@ -341,7 +755,7 @@ func (s *scanner) scan_dec_digits() bool {
 
 func (s *scanner) scan_hex_digits() bool {
 	ok := false
-	for '0' <= s.chr && s.chr <= '9' || 'A' <= s.chr && s.chr <= 'F' || 'a' <= s.chr && s.chr <= 'f' {
+	for jsontext.IsHexDigit(s.chr) {
 		ok = true
 		s.next()
 	}
@@ -357,8 +771,10 @@ func (s *scanner) scan_sign() bool {
 
 func (s *scanner) skip_whitespace() {
 	for {
-		if s.chr == ' ' || s.chr == '\t' || s.chr == '\f' || s.chr == '\r' || s.chr == '\n' {
+		if jsontext.IsSpace(s.chr) {
 			s.next()
+		} else if s.chr == '/' && s.mode&Comments != 0 && s.skip_comment() {
+			// ok
 		} else {
 			break
 		}
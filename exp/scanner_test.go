@@ -1,6 +1,13 @@
 package xjson
 
-import "testing"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	root "github.com/fd/xjson"
+)
 
 func TestParse(t *testing.T) {
 	var js = `
@@ -31,3 +38,307 @@ func TestParse_2(t *testing.T) {
 	t.Logf("v=%+v, err=%s\n", v, err)
 	t.Logf("v=%j, err=%s\n", v, err)
 }
+
+func TestParseMode_strict(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{`{"a":1}`, ``},
+		{` {"a":1} `, ``},
+		{`{"a":1} garbage`, `xjson: unexpected byte 'g', expected end of input (line 1 col 9)`},
+		{`1 2`, `xjson: unexpected byte '2', expected end of input (line 1 col 3)`},
+	}
+
+	for _, test := range tests {
+		v := ParseMode([]byte(test.in), Strict)
+
+		var err string
+		if e, ok := v.(*errorValue); ok {
+			err = e.err.Error()
+		}
+		if err != test.err {
+			t.Errorf("%s: expected error %q, got %q", test.in, test.err, err)
+		}
+	}
+
+	if v := Parse([]byte(`{"a":1} garbage`)); v.Kind() != Object {
+		t.Errorf("Parse should ignore trailing data, got %s", v.Kind())
+	}
+}
+
+func TestParseMany(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out []string
+	}{
+		{``, nil},
+		{`{"a":1}{"a":2} [3] 4 "5"`, []string{`{"a":1}`, `{"a":2}`, `[3]`, `4`, `"5"`}},
+		{"\x1e{\"a\":1}\n\x1e[2]\n", []string{`{"a":1}`, `[2]`}},
+		{"\x1e{\"a\":\n\x1e[2]\n\x1e\n", []string{`Error`, `[2]`}},
+		{`1 [2,`, []string{`1`, `Error`}},
+	}
+
+	for _, test := range tests {
+		var out []string
+		for _, v := range ParseMany([]byte(test.in)) {
+			if v.Kind() == Error {
+				out = append(out, "Error")
+			} else {
+				out = append(out, string(raw_bytes(v)))
+			}
+		}
+
+		if len(out) != len(test.out) {
+			t.Errorf("%q:\n  expected %q\n  got      %q", test.in, test.out, out)
+			continue
+		}
+		for i := range out {
+			if out[i] != test.out[i] {
+				t.Errorf("%q:\n  expected %q\n  got      %q", test.in, test.out, out)
+				break
+			}
+		}
+	}
+}
+
+func TestSpan(t *testing.T) {
+	var js = "{\n  \"a\": [1, true],\n  \"b\": {\"c\": \"x\"}\n}"
+
+	var tests = []struct {
+		path       []interface{}
+		start, end Position
+	}{
+		{nil, Position{Offset: 0, Line: 1, Column: 1}, Position{Offset: 39, Line: 4, Column: 2}},
+		{[]interface{}{"a"}, Position{Offset: 9, Line: 2, Column: 8}, Position{Offset: 18, Line: 2, Column: 17}},
+		{[]interface{}{"a", 1}, Position{Offset: 13, Line: 2, Column: 12}, Position{Offset: 17, Line: 2, Column: 16}},
+		{[]interface{}{"b", "c"}, Position{Offset: 33, Line: 3, Column: 14}, Position{Offset: 36, Line: 3, Column: 17}},
+	}
+
+	v := Parse([]byte(js))
+	for _, test := range tests {
+		start, end := v.Path(test.path...).Span()
+		if start != test.start || end != test.end {
+			t.Errorf("%v: expected %v-%v, got %v-%v", test.path, test.start, test.end, start, end)
+		}
+	}
+
+	values := ParseMany([]byte("\x1e1\n\x1e\"a\"\n"))
+	if start, _ := values[1].Span(); start != (Position{Offset: 4, Line: 2, Column: 2}) {
+		t.Errorf("expected the second record to start at line 2 col 2, got %v", start)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{"{\n\t\"a\": [1, 2 }\n}", `xjson: unexpected byte '}', expected ',' or ']' after array element (at: $root.a, line 2 col 13)`},
+		{`{"a": tru}`, `xjson: unexpected byte '}', expected "true" (at: $root.a, line 1 col 10)`},
+		{`[1, 2`, `xjson: unexpected end of input, expected ',' or ']' after array element (at: $root, line 1 col 6)`},
+		{`{"a" 1}`, `xjson: unexpected byte '1', expected ':' after object key (at: $root, line 1 col 6)`},
+		{`{"a":1,}`, `xjson: unexpected byte '}', expected object key (at: $root, line 1 col 8)`},
+		{`["a\x"]`, `xjson: unexpected byte 'x', expected escape sequence (at: $root[0], line 1 col 5)`},
+	}
+
+	for _, test := range tests {
+		v := Parse([]byte(test.in))
+
+		e, ok := v.(*errorValue)
+		if !ok {
+			t.Errorf("%q: expected an error", test.in)
+			continue
+		}
+		if _, ok := e.err.(*SyntaxError); !ok || e.err.Error() != test.err {
+			t.Errorf("%q:\n  expected %s\n  got      %s", test.in, test.err, e.err)
+		}
+	}
+
+	e := Parse([]byte("{\n\t\"a\": [1, 2 }\n}")).(*errorValue).err.(*SyntaxError)
+	if e.Snippet() != "\t\"a\": [1, 2 }\n\t           ^" {
+		t.Errorf("unexpected snippet\n%s", e.Snippet())
+	}
+}
+
+func TestParseTolerant(t *testing.T) {
+	var tests = []struct {
+		in   string
+		out  string
+		errs []string
+	}{
+		{`{"a":1}`, `{"a":1}`, nil},
+		{`[1, tru, 3]`, `[1,Error,3]`, []string{
+			`xjson: unexpected byte ',', expected "true" (at: $root[1], line 1 col 8)`,
+		}},
+		{`{"a": [1 2], "b" 3, "c": "\x", "d": {"e": nul}, "f": true}`, `{"a":[1],"b":Error,"c":Error,"d":{"e":Error},"f":true}`, []string{
+			`xjson: unexpected byte '2', expected ',' or ']' after array element (at: $root.a, line 1 col 10)`,
+			`xjson: unexpected byte '3', expected ':' after object key (at: $root, line 1 col 18)`,
+			`xjson: unexpected byte 'x', expected escape sequence (at: $root.c, line 1 col 28)`,
+			`xjson: unexpected byte '}', expected "null" (at: $root.d.e, line 1 col 46)`,
+		}},
+		{`[1, {"a": 2]`, `[1,{"a":2}]`, []string{
+			`xjson: unexpected byte ']', expected ',' or '}' after object member (at: $root[1], line 1 col 12)`,
+		}},
+		{`{"a":1,}`, `{"a":1}`, []string{
+			`xjson: unexpected byte '}', expected object key (at: $root, line 1 col 8)`,
+		}},
+		{`[1, [2, `, `[1,[2,Error]]`, []string{
+			`xjson: unexpected end of input, expected value (at: $root[1][1], line 1 col 9)`,
+		}},
+		{`@`, `Error`, []string{
+			`xjson: unexpected byte '@', expected value (at: $root, line 1 col 1)`,
+		}},
+		{`[1] x`, `[1]`, []string{
+			`xjson: unexpected byte 'x', expected end of input (line 1 col 5)`,
+		}},
+	}
+
+	for _, test := range tests {
+		v, errs := ParseTolerant([]byte(test.in), Strict)
+
+		if out := tolerant_string(v); out != test.out {
+			t.Errorf("%s:\n  expected %s\n  got      %s", test.in, test.out, out)
+		}
+
+		if len(errs) != len(test.errs) {
+			t.Errorf("%s: expected %d errors, got %d: %v", test.in, len(test.errs), len(errs), errs)
+			continue
+		}
+		for i, err := range errs {
+			if err.Error() != test.errs[i] {
+				t.Errorf("%s:\n  expected %s\n  got      %s", test.in, test.errs[i], err)
+			}
+		}
+	}
+}
+
+func tolerant_string(v Value) string {
+	switch v.Kind() {
+	case Error:
+		return "Error"
+	case Array:
+		var parts []string
+		for i, l := 0, v.Len(); i < l; i++ {
+			parts = append(parts, tolerant_string(v.Index(i)))
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	case Object:
+		var parts []string
+		for _, m := range v.(*objectValue).members {
+			parts = append(parts, strconv.Quote(m.key)+":"+tolerant_string(m.value))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	default:
+		return string(raw_bytes(v))
+	}
+}
+
+func TestParseMode_dialects(t *testing.T) {
+	var tests = []struct {
+		in   string
+		mode Mode
+		out  string
+	}{
+		{"[1, // one\n 2 /* two */]", JSONC, `[1,2]`},
+		{`{"a": 1, "b": [2,],}`, JSONC, `{"a":1,"b":[2]}`},
+		{`{'a': 'it\'s "x"', b: 'A\x41\
+B'}`, JSON5, `{"a":"it's \"x\"","b":"A\u0041B"}`},
+		{`{$id_1: 2}`, JSON5, `{"$id_1":2}`},
+		{`[0x1F, -0xff, 0xFFFFFFFFFFFFFFFFFF]`, JSON5, `[31,-255,4722366482869645213695]`},
+		{`[+1, .5, 5., -.5e1]`, JSON5, `[1,0.5,5,-0.5e1]`},
+		{`[Infinity, -Infinity, +Infinity, NaN]`, JSON5, `[Infinity,-Infinity,Infinity,NaN]`},
+		{`{"a": [1, 2]}`, JSON5, `{"a": [1, 2]}`},
+	}
+
+	for _, test := range tests {
+		v := ParseMode([]byte(test.in), test.mode|Strict)
+		if v.Kind() == Error {
+			t.Errorf("%s: unexpected error: %s", test.in, v.(*errorValue).err)
+			continue
+		}
+		if out := string(raw_bytes(v)); out != test.out {
+			t.Errorf("%s:\n  expected %s\n  got      %s", test.in, test.out, out)
+		}
+	}
+
+	if v := ParseMode([]byte(`[0x1F, 'a\x41']`), JSON5); v.Index(0).Int() != 31 || v.Index(1).String() != "aA" {
+		t.Errorf("unexpected values in %s", raw_bytes(v))
+	}
+
+	var strict = []string{
+		`[1, // one` + "\n" + `2]`,
+		`[1,]`,
+		`{"a":1,}`,
+		`'a'`,
+		`{a: 1}`,
+		`0x1F`,
+		`NaN`,
+		`.5`,
+		`5.`,
+		`+1`,
+	}
+	for _, in := range strict {
+		if v := ParseMode([]byte(in), Strict); v.Kind() != Error {
+			t.Errorf("%s: expected an error in strict json", in)
+		}
+	}
+}
+
+func TestParseMode_unterminatedComment(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{`[1, /* two`, `xjson: unexpected end of input, expected '*/' to close unterminated block comment (at: $root, line 1 col 11)`},
+		{`1 /* one`, `xjson: unexpected end of input, expected '*/' to close unterminated block comment (at: $root, line 1 col 9)`},
+		{`{"a": 1 /*`, `xjson: unexpected end of input, expected '*/' to close unterminated block comment (at: $root, line 1 col 11)`},
+		{`/*/`, `xjson: unexpected end of input, expected '*/' to close unterminated block comment (at: $root, line 1 col 4)`},
+	}
+
+	for _, test := range tests {
+		v := ParseMode([]byte(test.in), JSONC|Strict)
+		if v.Kind() != Error {
+			t.Errorf("%s: expected an error", test.in)
+			continue
+		}
+		err := v.(*errorValue).err
+		if _, ok := err.(*root.SyntaxError); !ok {
+			t.Errorf("%s: expected a *SyntaxError, got %T", test.in, err)
+		}
+		if err.Error() != test.err {
+			t.Errorf("%s:\n  expected %s\n  got      %s", test.in, test.err, err)
+		}
+	}
+
+	if _, errs := ParseTolerant([]byte(`[1, /* two`), JSONC); len(errs) != 1 {
+		t.Errorf("expected a single error, got %v", errs)
+	}
+}
+
+// TestParseMode_lexemes runs the inputs the root decoder is pinned to, so
+// both scanners accept and reject the same json.
+func TestParseMode_lexemes(t *testing.T) {
+	data, err := os.ReadFile("../internal/jsontext/testdata/lexemes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		in, err := strconv.Unquote(line[2:])
+		if err != nil {
+			t.Fatalf("malformed line %q", line)
+		}
+
+		v := ParseMode([]byte(in), Strict)
+		if line[0] == 'y' && v.Kind() == Error {
+			t.Errorf("%q: unexpected error: %s", in, v.(*errorValue).err)
+		}
+		if line[0] == 'n' && v.Kind() != Error {
+			t.Errorf("%q: expected an error", in)
+		}
+	}
+}
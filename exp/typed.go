@@ -0,0 +1,179 @@
+package xjson
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	root "github.com/fd/xjson"
+)
+
+// UUID is a 128 bit universally unique identifier, see xjson.UUID.
+type UUID = root.UUID
+
+func (x *errorValue) Time(layouts ...string) time.Time  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *zeroValue) Time(layouts ...string) time.Time   { return time.Time{} }
+func (x *nullValue) Time(layouts ...string) time.Time   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *boolValue) Time(layouts ...string) time.Time   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *numberValue) Time(layouts ...string) time.Time { panic(invalid_kind_error(String, x.Kind())) }
+func (x *arrayValue) Time(layouts ...string) time.Time  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *objectValue) Time(layouts ...string) time.Time { panic(invalid_kind_error(String, x.Kind())) }
+
+func (x *stringValue) Time(layouts ...string) time.Time {
+	v, err := parse_time(x.val, layouts)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (x *errorValue) MaybeTime(layouts ...string) (time.Time, bool)  { return time.Time{}, false }
+func (x *zeroValue) MaybeTime(layouts ...string) (time.Time, bool)   { return time.Time{}, false }
+func (x *nullValue) MaybeTime(layouts ...string) (time.Time, bool)   { return time.Time{}, false }
+func (x *boolValue) MaybeTime(layouts ...string) (time.Time, bool)   { return time.Time{}, false }
+func (x *numberValue) MaybeTime(layouts ...string) (time.Time, bool) { return time.Time{}, false }
+func (x *stringValue) MaybeTime(layouts ...string) (time.Time, bool) {
+	return maybe_time(parse_time(x.val, layouts))
+}
+func (x *arrayValue) MaybeTime(layouts ...string) (time.Time, bool)  { return time.Time{}, false }
+func (x *objectValue) MaybeTime(layouts ...string) (time.Time, bool) { return time.Time{}, false }
+
+func (x *errorValue) MustTime(layouts ...string) time.Time  { return time.Time{} }
+func (x *zeroValue) MustTime(layouts ...string) time.Time   { return time.Time{} }
+func (x *nullValue) MustTime(layouts ...string) time.Time   { return time.Time{} }
+func (x *boolValue) MustTime(layouts ...string) time.Time   { return time.Time{} }
+func (x *numberValue) MustTime(layouts ...string) time.Time { return time.Time{} }
+func (x *stringValue) MustTime(layouts ...string) time.Time {
+	return must_time(parse_time(x.val, layouts))
+}
+func (x *arrayValue) MustTime(layouts ...string) time.Time  { return time.Time{} }
+func (x *objectValue) MustTime(layouts ...string) time.Time { return time.Time{} }
+
+func maybe_time(v time.Time, err error) (time.Time, bool) { return v, err == nil }
+func must_time(v time.Time, err error) time.Time          { return v }
+
+func (x *errorValue) Duration() time.Duration  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *zeroValue) Duration() time.Duration   { return 0 }
+func (x *nullValue) Duration() time.Duration   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *boolValue) Duration() time.Duration   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *numberValue) Duration() time.Duration { panic(invalid_kind_error(String, x.Kind())) }
+func (x *arrayValue) Duration() time.Duration  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *objectValue) Duration() time.Duration { panic(invalid_kind_error(String, x.Kind())) }
+
+func (x *stringValue) Duration() time.Duration {
+	v, err := root.ParseDuration(x.val)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (x *errorValue) MaybeDuration() (time.Duration, bool)  { return 0, false }
+func (x *zeroValue) MaybeDuration() (time.Duration, bool)   { return 0, false }
+func (x *nullValue) MaybeDuration() (time.Duration, bool)   { return 0, false }
+func (x *boolValue) MaybeDuration() (time.Duration, bool)   { return 0, false }
+func (x *numberValue) MaybeDuration() (time.Duration, bool) { return 0, false }
+func (x *stringValue) MaybeDuration() (time.Duration, bool) {
+	return maybe_duration(root.ParseDuration(x.val))
+}
+func (x *arrayValue) MaybeDuration() (time.Duration, bool)  { return 0, false }
+func (x *objectValue) MaybeDuration() (time.Duration, bool) { return 0, false }
+
+func (x *errorValue) MustDuration() time.Duration  { return 0 }
+func (x *zeroValue) MustDuration() time.Duration   { return 0 }
+func (x *nullValue) MustDuration() time.Duration   { return 0 }
+func (x *boolValue) MustDuration() time.Duration   { return 0 }
+func (x *numberValue) MustDuration() time.Duration { return 0 }
+func (x *stringValue) MustDuration() time.Duration { return must_duration(root.ParseDuration(x.val)) }
+func (x *arrayValue) MustDuration() time.Duration  { return 0 }
+func (x *objectValue) MustDuration() time.Duration { return 0 }
+
+func maybe_duration(v time.Duration, err error) (time.Duration, bool) { return v, err == nil }
+func must_duration(v time.Duration, err error) time.Duration          { return v }
+
+func (x *errorValue) UUID() UUID  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *zeroValue) UUID() UUID   { return UUID{} }
+func (x *nullValue) UUID() UUID   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *boolValue) UUID() UUID   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *numberValue) UUID() UUID { panic(invalid_kind_error(String, x.Kind())) }
+func (x *arrayValue) UUID() UUID  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *objectValue) UUID() UUID { panic(invalid_kind_error(String, x.Kind())) }
+
+func (x *stringValue) UUID() UUID {
+	v, err := root.ParseUUID(x.val)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (x *errorValue) MaybeUUID() (UUID, bool)  { return UUID{}, false }
+func (x *zeroValue) MaybeUUID() (UUID, bool)   { return UUID{}, false }
+func (x *nullValue) MaybeUUID() (UUID, bool)   { return UUID{}, false }
+func (x *boolValue) MaybeUUID() (UUID, bool)   { return UUID{}, false }
+func (x *numberValue) MaybeUUID() (UUID, bool) { return UUID{}, false }
+func (x *stringValue) MaybeUUID() (UUID, bool) { return maybe_uuid(root.ParseUUID(x.val)) }
+func (x *arrayValue) MaybeUUID() (UUID, bool)  { return UUID{}, false }
+func (x *objectValue) MaybeUUID() (UUID, bool) { return UUID{}, false }
+
+func (x *errorValue) MustUUID() UUID  { return UUID{} }
+func (x *zeroValue) MustUUID() UUID   { return UUID{} }
+func (x *nullValue) MustUUID() UUID   { return UUID{} }
+func (x *boolValue) MustUUID() UUID   { return UUID{} }
+func (x *numberValue) MustUUID() UUID { return UUID{} }
+func (x *stringValue) MustUUID() UUID { return must_uuid(root.ParseUUID(x.val)) }
+func (x *arrayValue) MustUUID() UUID  { return UUID{} }
+func (x *objectValue) MustUUID() UUID { return UUID{} }
+
+func maybe_uuid(v UUID, err error) (UUID, bool) { return v, err == nil }
+func must_uuid(v UUID, err error) UUID          { return v }
+
+func (x *errorValue) Bytes() []byte  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *zeroValue) Bytes() []byte   { return nil }
+func (x *nullValue) Bytes() []byte   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *boolValue) Bytes() []byte   { panic(invalid_kind_error(String, x.Kind())) }
+func (x *numberValue) Bytes() []byte { panic(invalid_kind_error(String, x.Kind())) }
+func (x *arrayValue) Bytes() []byte  { panic(invalid_kind_error(String, x.Kind())) }
+func (x *objectValue) Bytes() []byte { panic(invalid_kind_error(String, x.Kind())) }
+
+func (x *stringValue) Bytes() []byte {
+	v, err := root.DecodeBase64(x.val)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (x *errorValue) MaybeBytes() ([]byte, bool)  { return nil, false }
+func (x *zeroValue) MaybeBytes() ([]byte, bool)   { return nil, false }
+func (x *nullValue) MaybeBytes() ([]byte, bool)   { return nil, false }
+func (x *boolValue) MaybeBytes() ([]byte, bool)   { return nil, false }
+func (x *numberValue) MaybeBytes() ([]byte, bool) { return nil, false }
+func (x *stringValue) MaybeBytes() ([]byte, bool) { return maybe_bytes(root.DecodeBase64(x.val)) }
+func (x *arrayValue) MaybeBytes() ([]byte, bool)  { return nil, false }
+func (x *objectValue) MaybeBytes() ([]byte, bool) { return nil, false }
+
+func (x *errorValue) MustBytes() []byte  { return nil }
+func (x *zeroValue) MustBytes() []byte   { return nil }
+func (x *nullValue) MustBytes() []byte   { return nil }
+func (x *boolValue) MustBytes() []byte   { return nil }
+func (x *numberValue) MustBytes() []byte { return nil }
+func (x *stringValue) MustBytes() []byte { return must_bytes(root.DecodeBase64(x.val)) }
+func (x *arrayValue) MustBytes() []byte  { return nil }
+func (x *objectValue) MustBytes() []byte { return nil }
+
+func maybe_bytes(v []byte, err error) ([]byte, bool) { return v, err == nil }
+func must_bytes(v []byte, err error) []byte          { return v }
+
+// parse_time parses s in one of layouts, which defaults to RFC 3339.
+func parse_time(s string, layouts []string) (time.Time, error) {
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("xjson: %q does not match %s", s, strings.Join(layouts, " or "))
+}
@@ -2,8 +2,10 @@ package xjson
 
 import (
 	"fmt"
+	"math/big"
 	"sort"
 	"strconv"
+	"time"
 )
 
 type Value interface {
@@ -29,6 +31,22 @@ type Value interface {
 	MaybeUint() (uint64, bool)
 	MustUint() uint64
 
+	Time(layouts ...string) time.Time
+	MaybeTime(layouts ...string) (time.Time, bool)
+	MustTime(layouts ...string) time.Time
+
+	Duration() time.Duration
+	MaybeDuration() (time.Duration, bool)
+	MustDuration() time.Duration
+
+	UUID() UUID
+	MaybeUUID() (UUID, bool)
+	MustUUID() UUID
+
+	Bytes() []byte
+	MaybeBytes() ([]byte, bool)
+	MustBytes() []byte
+
 	Interface() interface{}
 	IsNil() bool
 	Len() int
@@ -37,6 +55,15 @@ type Value interface {
 	MapIndex(key string) Value
 	Path(parts ...interface{}) Value
 
+	MergePatch(patch Value) Value
+
+	Equal(other Value) bool
+	Hash() uint64
+
+	Canonical() ([]byte, error)
+
+	Span() (start, end Position)
+
 	Selector() interface{}
 
 	jsonValue()
@@ -48,25 +75,33 @@ type errorValue struct {
 type zeroValue struct {
 }
 type nullValue struct {
-	buf []byte
+	buf  []byte
+	span span
 }
 type boolValue struct {
-	buf []byte
-	val bool
+	buf  []byte
+	val  bool
+	span span
 }
 type numberValue struct {
 	buf   []byte
 	flags numberFlags
+	span  span
 }
 type stringValue struct {
-	buf []byte
-	val string
+	buf  []byte
+	val  string
+	span span
 }
 type arrayValue struct {
+	buf    []byte
 	values []Value
+	span   span
 }
 type objectValue struct {
+	buf     []byte
 	members []objectMember
+	span    span
 }
 type objectMember struct {
 	key   string
@@ -196,18 +231,68 @@ func (x *arrayValue) Int() int64  { panic(invalid_kind_error(Number, x.Kind()))
 func (x *objectValue) Int() int64 { panic(invalid_kind_error(Number, x.Kind())) }
 
 func (x *numberValue) Int() int64 {
-	if x.flags&(numberHasExponent|numberHasFraction) > 0 {
-		return int64(x.Float())
+	i, err := x.int_value()
+	if err != nil {
+		panic(err)
 	}
-	i, _ := strconv.ParseInt(string(x.buf), 10, 64)
 	return i
 }
 
+// int_value fails when x has a fraction or doesn't fit an int64.
+func (x *numberValue) int_value() (int64, error) {
+	if x.flags&(numberHasExponent|numberHasFraction) == 0 {
+		if i, err := strconv.ParseInt(string(x.buf), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+
+	n, err := x.whole_number()
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("xjson: %s is out of range for int64", x.buf)
+	}
+	return n.Int64(), nil
+}
+
+// uint_value fails when x has a fraction, is negative or doesn't fit a
+// uint64.
+func (x *numberValue) uint_value() (uint64, error) {
+	if x.flags&(numberHasExponent|numberHasFraction|numberIsNegative) == 0 {
+		if i, err := strconv.ParseUint(string(x.buf), 10, 64); err == nil {
+			return i, nil
+		}
+	}
+
+	n, err := x.whole_number()
+	if err != nil {
+		return 0, err
+	}
+	if n.Sign() < 0 || !n.IsUint64() {
+		return 0, fmt.Errorf("xjson: %s is out of range for uint64", x.buf)
+	}
+	return n.Uint64(), nil
+}
+
+func maybe_int(i int64, err error) (int64, bool)    { return i, err == nil }
+func maybe_uint(i uint64, err error) (uint64, bool) { return i, err == nil }
+func must_int(i int64, err error) int64             { return i }
+func must_uint(i uint64, err error) uint64          { return i }
+
+func (x *numberValue) whole_number() (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(string(x.buf))
+	if !ok || !r.IsInt() {
+		return nil, fmt.Errorf("xjson: %s is not an integer", x.buf)
+	}
+	return r.Num(), nil
+}
+
 func (x *errorValue) MaybeInt() (int64, bool)  { return 0, false }
 func (x *zeroValue) MaybeInt() (int64, bool)   { return 0, false }
 func (x *nullValue) MaybeInt() (int64, bool)   { return 0, false }
 func (x *boolValue) MaybeInt() (int64, bool)   { return 0, false }
-func (x *numberValue) MaybeInt() (int64, bool) { return x.Int(), true }
+func (x *numberValue) MaybeInt() (int64, bool) { return maybe_int(x.int_value()) }
 func (x *stringValue) MaybeInt() (int64, bool) { return 0, false }
 func (x *arrayValue) MaybeInt() (int64, bool)  { return 0, false }
 func (x *objectValue) MaybeInt() (int64, bool) { return 0, false }
@@ -216,7 +301,7 @@ func (x *errorValue) MustInt() int64  { return 0 }
 func (x *zeroValue) MustInt() int64   { return 0 }
 func (x *nullValue) MustInt() int64   { return 0 }
 func (x *boolValue) MustInt() int64   { return 0 }
-func (x *numberValue) MustInt() int64 { return x.Int() }
+func (x *numberValue) MustInt() int64 { return must_int(x.int_value()) }
 func (x *stringValue) MustInt() int64 { return 0 }
 func (x *arrayValue) MustInt() int64  { return 0 }
 func (x *objectValue) MustInt() int64 { return 0 }
@@ -230,17 +315,18 @@ func (x *arrayValue) Uint() uint64  { panic(invalid_kind_error(Number, x.Kind())
 func (x *objectValue) Uint() uint64 { panic(invalid_kind_error(Number, x.Kind())) }
 
 func (x *numberValue) Uint() uint64 {
-	if x.flags&(numberHasExponent|numberHasFraction) > 0 {
-		return uint64(x.Float())
+	i, err := x.uint_value()
+	if err != nil {
+		panic(err)
 	}
-	return uint64(x.Int())
+	return i
 }
 
 func (x *errorValue) MaybeUint() (uint64, bool)  { return 0, false }
 func (x *zeroValue) MaybeUint() (uint64, bool)   { return 0, false }
 func (x *nullValue) MaybeUint() (uint64, bool)   { return 0, false }
 func (x *boolValue) MaybeUint() (uint64, bool)   { return 0, false }
-func (x *numberValue) MaybeUint() (uint64, bool) { return x.Uint(), true }
+func (x *numberValue) MaybeUint() (uint64, bool) { return maybe_uint(x.uint_value()) }
 func (x *stringValue) MaybeUint() (uint64, bool) { return 0, false }
 func (x *arrayValue) MaybeUint() (uint64, bool)  { return 0, false }
 func (x *objectValue) MaybeUint() (uint64, bool) { return 0, false }
@@ -249,7 +335,7 @@ func (x *errorValue) MustUint() uint64  { return 0 }
 func (x *zeroValue) MustUint() uint64   { return 0 }
 func (x *nullValue) MustUint() uint64   { return 0 }
 func (x *boolValue) MustUint() uint64   { return 0 }
-func (x *numberValue) MustUint() uint64 { return x.Uint() }
+func (x *numberValue) MustUint() uint64 { return must_uint(x.uint_value()) }
 func (x *stringValue) MustUint() uint64 { return 0 }
 func (x *arrayValue) MustUint() uint64  { return 0 }
 func (x *objectValue) MustUint() uint64 { return 0 }
@@ -361,6 +447,51 @@ func (x *objectValue) Path(parts ...interface{}) Value {
 	return zero
 }
 
+func (x *errorValue) MergePatch(patch Value) Value  { return x }
+func (x *zeroValue) MergePatch(patch Value) Value   { return merge_patch(x, patch) }
+func (x *nullValue) MergePatch(patch Value) Value   { return merge_patch(x, patch) }
+func (x *boolValue) MergePatch(patch Value) Value   { return merge_patch(x, patch) }
+func (x *numberValue) MergePatch(patch Value) Value { return merge_patch(x, patch) }
+func (x *stringValue) MergePatch(patch Value) Value { return merge_patch(x, patch) }
+func (x *arrayValue) MergePatch(patch Value) Value  { return merge_patch(x, patch) }
+func (x *objectValue) MergePatch(patch Value) Value { return merge_patch(x, patch) }
+
+func (x *errorValue) Equal(other Value) bool  { return equal_values(x, other) }
+func (x *zeroValue) Equal(other Value) bool   { return equal_values(x, other) }
+func (x *nullValue) Equal(other Value) bool   { return equal_values(x, other) }
+func (x *boolValue) Equal(other Value) bool   { return equal_values(x, other) }
+func (x *numberValue) Equal(other Value) bool { return equal_values(x, other) }
+func (x *stringValue) Equal(other Value) bool { return equal_values(x, other) }
+func (x *arrayValue) Equal(other Value) bool  { return equal_values(x, other) }
+func (x *objectValue) Equal(other Value) bool { return equal_values(x, other) }
+
+func (x *errorValue) Hash() uint64  { return hash_value(x) }
+func (x *zeroValue) Hash() uint64   { return hash_value(x) }
+func (x *nullValue) Hash() uint64   { return hash_value(x) }
+func (x *boolValue) Hash() uint64   { return hash_value(x) }
+func (x *numberValue) Hash() uint64 { return hash_value(x) }
+func (x *stringValue) Hash() uint64 { return hash_value(x) }
+func (x *arrayValue) Hash() uint64  { return hash_value(x) }
+func (x *objectValue) Hash() uint64 { return hash_value(x) }
+
+func (x *errorValue) Canonical() ([]byte, error)  { return nil, x.err }
+func (x *zeroValue) Canonical() ([]byte, error)   { return canonical(x) }
+func (x *nullValue) Canonical() ([]byte, error)   { return canonical(x) }
+func (x *boolValue) Canonical() ([]byte, error)   { return canonical(x) }
+func (x *numberValue) Canonical() ([]byte, error) { return canonical(x) }
+func (x *stringValue) Canonical() ([]byte, error) { return canonical(x) }
+func (x *arrayValue) Canonical() ([]byte, error)  { return canonical(x) }
+func (x *objectValue) Canonical() ([]byte, error) { return canonical(x) }
+
+func (x *errorValue) Span() (start, end Position)  { return }
+func (x *zeroValue) Span() (start, end Position)   { return }
+func (x *nullValue) Span() (start, end Position)   { return x.span.positions() }
+func (x *boolValue) Span() (start, end Position)   { return x.span.positions() }
+func (x *numberValue) Span() (start, end Position) { return x.span.positions() }
+func (x *stringValue) Span() (start, end Position) { return x.span.positions() }
+func (x *arrayValue) Span() (start, end Position)  { return x.span.positions() }
+func (x *objectValue) Span() (start, end Position) { return x.span.positions() }
+
 func (x *numberValue) IsFloat() bool {
 	return x.flags&(numberHasExponent|numberHasFraction) > 0
 }
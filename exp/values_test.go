@@ -0,0 +1,76 @@
+package xjson
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNumberValue_Int(t *testing.T) {
+	var tests = []struct {
+		in   string
+		i    int64
+		i_ok bool
+		u    uint64
+		u_ok bool
+	}{
+		{`3`, 3, true, 3, true},
+		{`3.0`, 3, true, 3, true},
+		{`1e3`, 1000, true, 1000, true},
+		{`3.9`, 0, false, 0, false},
+		{`-1`, -1, true, 0, false},
+		{`9223372036854775808`, 0, false, 9223372036854775808, true},
+		{`18446744073709551616`, 0, false, 0, false},
+	}
+
+	for _, test := range tests {
+		v := Parse([]byte(test.in))
+
+		if i, ok := v.MaybeInt(); i != test.i || ok != test.i_ok {
+			t.Errorf("%s: expected MaybeInt() = %d, %v got %d, %v", test.in, test.i, test.i_ok, i, ok)
+		}
+		if u, ok := v.MaybeUint(); u != test.u || ok != test.u_ok {
+			t.Errorf("%s: expected MaybeUint() = %d, %v got %d, %v", test.in, test.u, test.u_ok, u, ok)
+		}
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("expected Int() to panic for a fraction")
+		}
+	}()
+	Parse([]byte(`3.9`)).Int()
+}
+
+func TestStringValue_typed(t *testing.T) {
+	v := Parse([]byte(`{"t": "2020-05-01T12:30:00Z", "d": "PT1M30S", "u": "123e4567-e89b-12d3-a456-426614174000", "b": "aGk=", "x": "not valid!"}`))
+
+	if tm := v.MapIndex("t").Time(); !tm.Equal(time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time %s", tm)
+	}
+	if d := v.MapIndex("d").Duration(); d != 90*time.Second {
+		t.Errorf("unexpected duration %s", d)
+	}
+	if u := v.MapIndex("u").UUID(); u.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected uuid %s", u)
+	}
+	if b := v.MapIndex("b").Bytes(); string(b) != "hi" {
+		t.Errorf("unexpected bytes %q", b)
+	}
+
+	x := v.MapIndex("x")
+	if _, ok := x.MaybeTime(); ok {
+		t.Errorf("expected MaybeTime() to fail")
+	}
+	if _, ok := x.MaybeDuration(); ok {
+		t.Errorf("expected MaybeDuration() to fail")
+	}
+	if _, ok := x.MaybeUUID(); ok {
+		t.Errorf("expected MaybeUUID() to fail")
+	}
+	if x.MustBytes() != nil {
+		t.Errorf("expected MustBytes() to return nil")
+	}
+	if d := v.MapIndex("missing").Duration(); d != 0 {
+		t.Errorf("expected a missing value to have no duration")
+	}
+}
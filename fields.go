@@ -0,0 +1,257 @@
+package xjson
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// field describes a struct field that can be filled from a json object
+// member. The resolution rules mirror those of encoding/json.
+type field struct {
+	name   string
+	tagged bool
+	index  []int
+	typ    reflect.Type
+	quoted bool
+}
+
+var field_cache struct {
+	sync.RWMutex
+	m map[reflect.Type][]field
+}
+
+func cached_fields(t reflect.Type) []field {
+	field_cache.RLock()
+	f, found := field_cache.m[t]
+	field_cache.RUnlock()
+	if found {
+		return f
+	}
+
+	f = type_fields(t)
+
+	field_cache.Lock()
+	if field_cache.m == nil {
+		field_cache.m = map[reflect.Type][]field{}
+	}
+	field_cache.m[t] = f
+	field_cache.Unlock()
+
+	return f
+}
+
+func type_fields(t reflect.Type) []field {
+	var (
+		current    []field
+		next       = []field{{typ: t}}
+		count      map[reflect.Type]int
+		next_count = map[reflect.Type]int{}
+		visited    = map[reflect.Type]bool{}
+		fields     []field
+	)
+
+	// walk the embedded structs breadth first
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, next_count = next_count, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+
+				if sf.Anonymous {
+					t := sf.Type
+					if t.Kind() == reflect.Ptr {
+						t = t.Elem()
+					}
+					if sf.PkgPath != "" && t.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				name, opts := parse_tag(tag)
+				if !is_valid_tag(name) {
+					name = ""
+				}
+
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				quoted := false
+				if has_tag_option(opts, "string") {
+					switch ft.Kind() {
+					case reflect.Bool,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64,
+						reflect.String:
+						quoted = true
+					}
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					tagged := name != ""
+					if name == "" {
+						name = sf.Name
+					}
+					fields = append(fields, field{name, tagged, index, ft, quoted})
+					if count[f.typ] > 1 {
+						// the same struct was embedded more than once at this
+						// depth; add a duplicate so it gets annihilated below.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				next_count[ft]++
+				if next_count[ft] == 1 {
+					next = append(next, field{name: ft.Name(), index: index, typ: ft})
+				}
+			}
+		}
+	}
+
+	sort.Sort(fields_by_name(fields))
+
+	// drop the fields hidden by the Go rules for embedded fields,
+	// except that tagged fields are promoted.
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fi.name {
+				break
+			}
+		}
+		if advance == 1 {
+			out = append(out, fi)
+			continue
+		}
+		if dominant, ok := dominant_field(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	fields = out
+	sort.Sort(fields_by_index(fields))
+
+	return fields
+}
+
+func dominant_field(fields []field) (field, bool) {
+	if len(fields) > 1 &&
+		len(fields[0].index) == len(fields[1].index) &&
+		fields[0].tagged == fields[1].tagged {
+		return field{}, false
+	}
+	return fields[0], true
+}
+
+type fields_by_name []field
+
+func (l fields_by_name) Len() int      { return len(l) }
+func (l fields_by_name) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l fields_by_name) Less(i, j int) bool {
+	if l[i].name != l[j].name {
+		return l[i].name < l[j].name
+	}
+	if len(l[i].index) != len(l[j].index) {
+		return len(l[i].index) < len(l[j].index)
+	}
+	if l[i].tagged != l[j].tagged {
+		return l[i].tagged
+	}
+	return fields_by_index(l).Less(i, j)
+}
+
+type fields_by_index []field
+
+func (l fields_by_index) Len() int      { return len(l) }
+func (l fields_by_index) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l fields_by_index) Less(i, j int) bool {
+	for k, x := range l[i].index {
+		if k >= len(l[j].index) {
+			return false
+		}
+		if x != l[j].index[k] {
+			return x < l[j].index[k]
+		}
+	}
+	return len(l[i].index) < len(l[j].index)
+}
+
+func parse_tag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+func has_tag_option(opts, name string) bool {
+	for opts != "" {
+		var opt string
+		if i := strings.Index(opts, ","); i >= 0 {
+			opt, opts = opts[:i], opts[i+1:]
+		} else {
+			opt, opts = opts, ""
+		}
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+func is_valid_tag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// allowed punctuation
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
+
+// field_by_index walks the index path of a field, allocating nil embedded
+// struct pointers along the way.
+func field_by_index(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
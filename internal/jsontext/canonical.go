@@ -0,0 +1,155 @@
+package jsontext
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Number returns the double RFC 8785 formats for the json number literal
+// lit. Integers must be represented exactly, fractions are rounded like
+// any other parser would.
+func Number(lit string) (float64, error) {
+	if !is_number(lit) {
+		return 0, fmt.Errorf("xjson: %s is not a valid json number", lit)
+	}
+
+	if !strings.ContainsAny(lit, ".eE") {
+		i, _ := new(big.Int).SetString(lit, 10)
+		f, acc := new(big.Float).SetInt(i).Float64()
+		if acc != big.Exact {
+			return 0, fmt.Errorf("xjson: %s cannot be represented exactly as a double", lit)
+		}
+		return f, nil
+	}
+
+	f, err := strconv.ParseFloat(lit, 64)
+	if err != nil {
+		return 0, fmt.Errorf("xjson: %s cannot be represented as a double", lit)
+	}
+	return f, nil
+}
+
+// WriteNumber formats f the way ECMAScript's Number.toString does.
+func WriteNumber(buf *bytes.Buffer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("xjson: %v is not a valid json number", f)
+	}
+	if f == 0 {
+		buf.WriteByte('0')
+		return nil
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+
+	b := strconv.AppendFloat(nil, f, format, -1, 64)
+	if format == 'e' {
+		// ECMAScript does not pad the exponent: 1e-07 becomes 1e-7
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	buf.Write(b)
+	return nil
+}
+
+// WriteString writes s as a json string with the minimal escaping of
+// RFC 8785. It fails when s is not valid UTF-8.
+func WriteString(buf *bytes.Buffer, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("xjson: invalid UTF-8 in string %q", s)
+	}
+
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\b':
+			buf.WriteString(`\b`)
+		case c == '\f':
+			buf.WriteString(`\f`)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+	return nil
+}
+
+// LessUTF16 orders object keys by their UTF-16 code units, as required by
+// RFC 8785.
+func LessUTF16(a, b string) bool {
+	var (
+		x = utf16.Encode([]rune(a))
+		y = utf16.Encode([]rune(b))
+	)
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return x[i] < y[i]
+		}
+	}
+	return len(x) < len(y)
+}
+
+// is_number reports whether lit matches the number grammar of RFC 8259.
+func is_number(lit string) bool {
+	i := 0
+	digits := func() bool {
+		beg := i
+		for i < len(lit) && '0' <= lit[i] && lit[i] <= '9' {
+			i++
+		}
+		return i > beg
+	}
+
+	if i < len(lit) && lit[i] == '-' {
+		i++
+	}
+	if i < len(lit) && lit[i] == '0' {
+		i++
+	} else if !digits() {
+		return false
+	}
+	if i < len(lit) && lit[i] == '.' {
+		i++
+		if !digits() {
+			return false
+		}
+	}
+	if i < len(lit) && (lit[i] == 'e' || lit[i] == 'E') {
+		i++
+		if i < len(lit) && (lit[i] == '+' || lit[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return i == len(lit)
+}
@@ -0,0 +1,39 @@
+package jsontext
+
+import (
+	"testing"
+)
+
+func TestNumber(t *testing.T) {
+	var tests = []struct {
+		lit string
+		out float64
+		err string
+	}{
+		{`0`, 0, ``},
+		{`-0`, 0, ``},
+		{`9007199254740992`, 1 << 53, ``},
+		{`9223372036854775808`, 1 << 63, ``},
+		{`0.1`, 0.1, ``},
+		{`1e-400`, 0, ``},
+		{`9007199254740993`, 0, `xjson: 9007199254740993 cannot be represented exactly as a double`},
+		{`1e400`, 0, `xjson: 1e400 cannot be represented as a double`},
+		{`01`, 0, `xjson: 01 is not a valid json number`},
+		{`1.`, 0, `xjson: 1. is not a valid json number`},
+		{`NaN`, 0, `xjson: NaN is not a valid json number`},
+		{``, 0, `xjson:  is not a valid json number`},
+	}
+
+	for _, test := range tests {
+		out, err := Number(test.lit)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%q: expected error %q, got %v", test.lit, test.err, err)
+			}
+			continue
+		}
+		if err != nil || out != test.out {
+			t.Errorf("%q: expected %v, got %v (%v)", test.lit, test.out, out, err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package jsontext
+
+// The xjson package owns the Selector and SyntaxError types, which exp
+// can't build without them being exported. xjson fills in these functions
+// when it is initialized; exp imports xjson so they are always set.
+var (
+	// PathError locates err at path, a list of keys and indices from the
+	// root, like xjson.Value.GetPath takes.
+	PathError func(err error, path []interface{}) error
+
+	// NewSyntaxError reports the byte at offset in src as an
+	// *xjson.SyntaxError in the value at path. A nil path is outside of
+	// any value, like trailing data.
+	NewSyntaxError func(src []byte, offset int, expected string, path []interface{}) error
+)
@@ -0,0 +1,16 @@
+// Package jsontext holds the parts of reading and writing json text that
+// the xjson and exp packages share, so that both accept and produce the
+// same json.
+package jsontext
+
+// IsSpace reports whether c is json whitespace. RFC 8259 only allows
+// space, tab, line feed and carriage return; form feeds and other unicode
+// spaces are rejected.
+func IsSpace(c int) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// IsHexDigit reports whether c is a hex digit, as used by \u escapes.
+func IsHexDigit(c int) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F' || 'a' <= c && c <= 'f'
+}
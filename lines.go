@@ -0,0 +1,99 @@
+package xjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// LineReader reads newline delimited json (JSON Lines). Every non-blank line
+// is parsed into its own Value, rooted at `$line[n]` where n is the 1-based
+// line number.
+type LineReader struct {
+	r      *bufio.Reader
+	line   int
+	offset int
+	value  Value
+	err    error
+}
+
+func ReadLines(r io.Reader) *LineReader {
+	return &LineReader{r: bufio.NewReader(r)}
+}
+
+// Next advances to the next non-blank line. It returns false at the end of
+// the input or when reading fails. A line that is not valid json does not
+// stop the reader; its Value holds the error instead.
+func (l *LineReader) Next() bool {
+	for l.err == nil {
+		b, err := l.r.ReadBytes('\n')
+		if err != nil {
+			l.err = err
+			if len(b) == 0 {
+				break
+			}
+		}
+		l.line++
+		l.offset += len(b)
+
+		if len(bytes.TrimSpace(b)) == 0 {
+			continue
+		}
+
+		v := parse(bytes.TrimRight(b, "\r\n"), Position{l.offset - len(b), l.line, 1})
+		sel := &line_selector{v.inner, l.line, nil}
+		if root, ok := v.selector.(*root_selector); ok {
+			sel.spans = root.spans
+		}
+		switch e := v.err.(type) {
+		case nil:
+		case *SyntaxError:
+			parts, _ := selector_path(e.Selector)
+			e.Selector = path_selector_from(sel, parts)
+		default:
+			v.err = &selector_error{v.err, sel}
+		}
+		l.value = Value{v.inner, v.err, sel}
+		return true
+	}
+
+	l.value = Value{}
+	return false
+}
+
+// Value returns the value of the current line.
+func (l *LineReader) Value() Value {
+	return l.value
+}
+
+// Line returns the line number of the current line.
+func (l *LineReader) Line() int {
+	return l.line
+}
+
+// Err returns the error that stopped the reader, if it wasn't io.EOF.
+func (l *LineReader) Err() error {
+	if l.err == io.EOF {
+		return nil
+	}
+	return l.err
+}
+
+// LineWriter writes values as newline delimited json, one compact value per
+// line.
+type LineWriter struct {
+	enc *json.Encoder
+}
+
+func NewLineWriter(w io.Writer) *LineWriter {
+	return &LineWriter{json.NewEncoder(w)}
+}
+
+func (l *LineWriter) Write(x Value) error {
+	i, err := x.Interface()
+	if err != nil {
+		return err
+	}
+	return l.enc.Encode(i)
+}
@@ -0,0 +1,81 @@
+package xjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadLines(t *testing.T) {
+	var tests = []struct {
+		name string
+		in   io.Reader
+		out  []string
+		err  string
+	}{
+		{"empty", strings.NewReader(``), nil, ``},
+		{"blank lines", strings.NewReader("\n1\n\n  \n2\n\n"), []string{
+			`$line[2] 1`,
+			`$line[5] 2`,
+		}, ``},
+		{"crlf", strings.NewReader("{\"a\":1}\r\n\r\n[2]\r\n"), []string{
+			`$line[1] {"a":1}`,
+			`$line[3] [2]`,
+		}, ``},
+		{"no final newline", strings.NewReader("1\n\"last\""), []string{
+			`$line[1] 1`,
+			`$line[2] "last"`,
+		}, ``},
+		{"malformed line", strings.NewReader("1\n{\"a\":}\n3\n"), []string{
+			`$line[1] 1`,
+			`$line[2] (xjson: unexpected byte '}', expected value (at: $line[2].a, line 2 col 6))`,
+			`$line[3] 3`,
+		}, ``},
+		{"reader error", io.MultiReader(strings.NewReader("1\n2\n"), iotest.ErrReader(errors.New("boom"))), []string{
+			`$line[1] 1`,
+			`$line[2] 2`,
+		}, `boom`},
+	}
+
+	for _, test := range tests {
+		var (
+			out []string
+			r   = ReadLines(test.in)
+		)
+		for r.Next() {
+			v := r.Value()
+			out = append(out, v.Selector().String()+" "+describe_value(v))
+		}
+
+		if strings.Join(out, "\n") != strings.Join(test.out, "\n") {
+			t.Errorf("%s:\n  expected %q\n  got      %q", test.name, test.out, out)
+		}
+		if err := r.Err(); (err == nil && test.err != "") || (err != nil && err.Error() != test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+		}
+		if r.Next() {
+			t.Errorf("%s: expected Next to keep returning false", test.name)
+		}
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := NewLineWriter(&buf)
+	for _, in := range []string{`{"b": 1, "a": [true, null]}`, `"x"`} {
+		if err := w.Write(Parse([]byte(in))); err != nil {
+			t.Fatalf("%s: unexpected error: %s", in, err)
+		}
+	}
+	if buf.String() != "{\"a\":[true,null],\"b\":1}\n\"x\"\n" {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+
+	if err := w.Write(Parse([]byte(`{`))); err == nil {
+		t.Errorf("expected an error for an Error value")
+	}
+}
@@ -0,0 +1,80 @@
+package xjson
+
+// MergePatch applies a RFC 7386 JSON Merge Patch to x and returns the
+// result as a new root Value. Members set to null in the patch are removed,
+// non-object patches replace the target entirely.
+func (x Value) MergePatch(patch Value) Value {
+	if x.err != nil {
+		return x
+	}
+	if patch.err != nil {
+		return patch
+	}
+
+	inner := merge_patch(x.inner, patch.inner)
+	return Value{inner, nil, &root_selector{inner, nil}}
+}
+
+// CreateMergePatch returns the RFC 7386 JSON Merge Patch that transforms
+// original into modified. Note that merge patches cannot set members to
+// null; those are treated as removals.
+func CreateMergePatch(original, modified Value) Value {
+	if original.err != nil {
+		return original
+	}
+	if modified.err != nil {
+		return modified
+	}
+
+	inner := create_merge_patch(original.inner, modified.inner)
+	return Value{inner, nil, &root_selector{inner, nil}}
+}
+
+func merge_patch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, _ := target.(map[string]interface{})
+	c := copy_object(t, len(p))
+
+	for key, v := range p {
+		if v == nil {
+			delete(c, key)
+		} else {
+			c[key] = merge_patch(c[key], v)
+		}
+	}
+
+	return c
+}
+
+func create_merge_patch(original, modified interface{}) interface{} {
+	a, ok := original.(map[string]interface{})
+	if !ok {
+		return modified
+	}
+	b, ok := modified.(map[string]interface{})
+	if !ok {
+		return modified
+	}
+
+	patch := map[string]interface{}{}
+
+	for key := range a {
+		if _, found := b[key]; !found {
+			patch[key] = nil
+		}
+	}
+
+	for key, bv := range b {
+		av, found := a[key]
+		if found && equal_inner(av, bv) {
+			continue
+		}
+		patch[key] = create_merge_patch(av, bv)
+	}
+
+	return patch
+}
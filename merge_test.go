@@ -0,0 +1,63 @@
+package xjson
+
+import (
+	"testing"
+)
+
+func TestValue_MergePatch(t *testing.T) {
+	// RFC 7386, appendix A
+	var tests = []struct {
+		target, patch, out string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+
+		// nested merges
+		{`{"a":{"b":{"c":1,"d":2},"e":3}}`, `{"a":{"b":{"c":null,"f":4}}}`, `{"a":{"b":{"d":2,"f":4},"e":3}}`},
+		{`{"a":{"b":1}}`, `{"a":{"b":{"c":null}}}`, `{"a":{"b":{}}}`},
+		{`{"a":1}`, `{}`, `{"a":1}`},
+		{`{"a":1}`, `{"missing":null}`, `{"a":1}`},
+	}
+
+	for _, test := range tests {
+		var (
+			target = Parse([]byte(test.target))
+			patch  = Parse([]byte(test.patch))
+			out    = describe_value(target.MergePatch(patch))
+		)
+
+		if out != test.out {
+			t.Errorf("%s + %s:\n  expected %s\n  got      %s", test.target, test.patch, test.out, out)
+		}
+		if describe_value(target) != describe_value(Parse([]byte(test.target))) {
+			t.Errorf("%s + %s: modified the target", test.target, test.patch)
+		}
+
+		// patches without nulls can be recreated
+		created := CreateMergePatch(target, target.MergePatch(patch))
+		if out := describe_value(target.MergePatch(created)); out != test.out {
+			t.Errorf("CreateMergePatch(%s, %s) = %s does not round trip", test.target, test.out, describe_value(created))
+		}
+	}
+
+	broken := Parse([]byte(`{`))
+	if v := broken.MergePatch(Parse([]byte(`{}`))); v.Kind() != Error {
+		t.Errorf("expected the error of the target to be kept")
+	}
+	if v := Parse([]byte(`{}`)).MergePatch(broken); v.Kind() != Error {
+		t.Errorf("expected the error of the patch to be kept")
+	}
+}
@@ -0,0 +1,346 @@
+package xjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Numbers are held as int64 or float64 when that doesn't lose any digits of
+// their literal, and as json.Number otherwise. This way 9007199254740993,
+// 18446744073709551615 or 0.10000000000000000001 survive a round trip.
+
+// parse_number converts a json number literal to int64, float64 or, when
+// neither is exact, json.Number.
+func parse_number(lit string) interface{} {
+	if i, err := strconv.ParseInt(lit, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(lit, 64); err == nil && exact_float(lit, f) {
+		return f
+	}
+	return json.Number(lit)
+}
+
+// exact_float reports whether f, formatted in the shortest way, has the
+// same value as lit.
+func exact_float(lit string, f float64) bool {
+	if math.IsInf(f, 0) {
+		return false
+	}
+
+	// every literal with up to 15 significant digits survives a normal
+	// float64 unharmed
+	if significant_digits(lit) <= 15 && (f == 0 || math.Abs(f) >= 0x1p-1022) {
+		return f != 0 || significant_digits(lit) == 0
+	}
+
+	a, ok := new(big.Rat).SetString(lit)
+	if !ok {
+		return false
+	}
+	b, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return a.Cmp(b) == 0
+}
+
+// significant_digits counts the digits of lit's mantissa, without leading
+// and trailing zeros.
+func significant_digits(lit string) int {
+	var (
+		n     int
+		zeros int
+	)
+
+	for i := 0; i < len(lit); i++ {
+		c := lit[i]
+		if c == 'e' || c == 'E' {
+			break
+		}
+		if c < '0' || c > '9' {
+			continue
+		}
+		if c == '0' {
+			if n > 0 {
+				zeros++
+			}
+			continue
+		}
+		n += zeros + 1
+		zeros = 0
+	}
+
+	return n
+}
+
+// number_literal formats a number held by a Value as a json literal.
+func number_literal(i interface{}) string {
+	switch x := i.(type) {
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case json.Number:
+		return string(x)
+	}
+	panic("number_literal() expects a json number")
+}
+
+// number_rat returns the exact value of a number held by a Value. It fails
+// for literals like 1e1000000000 whose exponent big.Rat refuses.
+func number_rat(i interface{}) (*big.Rat, error) {
+	switch x := i.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(x), nil
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, fmt.Errorf("xjson: %v is not a valid json number", x)
+		}
+		return new(big.Rat).SetFloat64(x), nil
+	case json.Number:
+		r, ok := new(big.Rat).SetString(string(x))
+		if !ok {
+			return nil, fmt.Errorf("xjson: %s cannot be represented exactly", x)
+		}
+		return r, nil
+	}
+	panic("number_rat() expects a json number")
+}
+
+func (x Value) number() (interface{}, error) {
+	i, err := x.Interface()
+	if err != nil {
+		return nil, err
+	}
+	if !is_number(i) {
+		return nil, type_conflict_error(x.inner, "json number", x.selector)
+	}
+	return i, nil
+}
+
+// Number returns the json literal of x. Floats are formatted with the
+// fewest digits that identify them.
+func (x Value) Number() (json.Number, error) {
+	i, err := x.number()
+	if err != nil {
+		return "", err
+	}
+	return json.Number(number_literal(i)), nil
+}
+
+// BigInt returns x as a big.Int. It fails when x has a fraction.
+func (x Value) BigInt() (*big.Int, error) {
+	n, err := x.whole_number("big.Int")
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(n), nil
+}
+
+// BigFloat returns x as a big.Float, with enough precision to hold all the
+// digits of its literal.
+func (x Value) BigFloat() (*big.Float, error) {
+	i, err := x.number()
+	if err != nil {
+		return nil, err
+	}
+
+	switch y := i.(type) {
+	case int64:
+		return new(big.Float).SetInt64(y), nil
+	case float64:
+		return big.NewFloat(y), nil
+	}
+
+	lit := number_literal(i)
+	prec := uint(4 * len(lit))
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(lit, 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, &selector_error{err, x.selector}
+	}
+	return f, nil
+}
+
+// Decimal returns x in decimal notation without an exponent, with exactly
+// as many fractional digits as needed, e.g. 1.5e-3 becomes "0.0015".
+func (x Value) Decimal() (string, error) {
+	i, err := x.number()
+	if err != nil {
+		return "", err
+	}
+
+	// floats are written with the digits of their shortest literal
+	r, err := number_rat(json.Number(number_literal(i)))
+	if err != nil {
+		return "", &selector_error{err, x.selector}
+	}
+
+	var (
+		places int
+		t      = new(big.Rat).Set(r)
+		ten    = big.NewRat(10, 1)
+	)
+	for !t.IsInt() {
+		t.Mul(t, ten)
+		places++
+	}
+	return r.FloatString(places), nil
+}
+
+func (x Value) MustNumber() json.Number {
+	v, _ := x.Number()
+	return v
+}
+
+func (x Value) MustBigInt() *big.Int {
+	v, _ := x.BigInt()
+	return v
+}
+
+func (x Value) MustBigFloat() *big.Float {
+	v, _ := x.BigFloat()
+	return v
+}
+
+func (x Value) MustDecimal() string {
+	v, _ := x.Decimal()
+	return v
+}
+
+func (x Value) Int() (int, error) {
+	i, err := x.int_value(math.MinInt, math.MaxInt, "int")
+	return int(i), err
+}
+
+func (x Value) Int32() (int32, error) {
+	i, err := x.int_value(math.MinInt32, math.MaxInt32, "int32")
+	return int32(i), err
+}
+
+func (x Value) Int16() (int16, error) {
+	i, err := x.int_value(math.MinInt16, math.MaxInt16, "int16")
+	return int16(i), err
+}
+
+func (x Value) Int8() (int8, error) {
+	i, err := x.int_value(math.MinInt8, math.MaxInt8, "int8")
+	return int8(i), err
+}
+
+func (x Value) Uint() (uint, error) {
+	i, err := x.uint_value(math.MaxUint, "uint")
+	return uint(i), err
+}
+
+func (x Value) Uint32() (uint32, error) {
+	i, err := x.uint_value(math.MaxUint32, "uint32")
+	return uint32(i), err
+}
+
+func (x Value) Uint16() (uint16, error) {
+	i, err := x.uint_value(math.MaxUint16, "uint16")
+	return uint16(i), err
+}
+
+func (x Value) Uint8() (uint8, error) {
+	i, err := x.uint_value(math.MaxUint8, "uint8")
+	return uint8(i), err
+}
+
+func (x Value) MustInt() int {
+	v, _ := x.Int()
+	return v
+}
+
+func (x Value) MustInt32() int32 {
+	v, _ := x.Int32()
+	return v
+}
+
+func (x Value) MustInt16() int16 {
+	v, _ := x.Int16()
+	return v
+}
+
+func (x Value) MustInt8() int8 {
+	v, _ := x.Int8()
+	return v
+}
+
+func (x Value) MustUint() uint {
+	v, _ := x.Uint()
+	return v
+}
+
+func (x Value) MustUint32() uint32 {
+	v, _ := x.Uint32()
+	return v
+}
+
+func (x Value) MustUint16() uint16 {
+	v, _ := x.Uint16()
+	return v
+}
+
+func (x Value) MustUint8() uint8 {
+	v, _ := x.Uint8()
+	return v
+}
+
+// int_value returns x when it is a whole number between min and max.
+func (x Value) int_value(min, max int64, t string) (int64, error) {
+	if y, ok := x.inner.(int64); ok && x.err == nil && min <= y && y <= max {
+		return y, nil
+	}
+	n, err := x.whole_number(t)
+	if err != nil {
+		return 0, err
+	}
+	if n.IsInt64() && min <= n.Int64() && n.Int64() <= max {
+		return n.Int64(), nil
+	}
+	return 0, x.out_of_range(t)
+}
+
+// uint_value returns x when it is a whole number between 0 and max.
+func (x Value) uint_value(max uint64, t string) (uint64, error) {
+	if y, ok := x.inner.(int64); ok && x.err == nil && 0 <= y && uint64(y) <= max {
+		return uint64(y), nil
+	}
+	n, err := x.whole_number(t)
+	if err != nil {
+		return 0, err
+	}
+	if n.Sign() >= 0 && n.IsUint64() && n.Uint64() <= max {
+		return n.Uint64(), nil
+	}
+	return 0, x.out_of_range(t)
+}
+
+// whole_number returns the number held by x, failing when it has a
+// fraction.
+func (x Value) whole_number(t string) (*big.Int, error) {
+	i, err := x.number()
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := i.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+		return nil, x.out_of_range(t)
+	}
+	r, err := number_rat(i)
+	if err != nil {
+		return nil, &selector_error{err, x.selector}
+	}
+	if !r.IsInt() {
+		return nil, &selector_error{fmt.Errorf("xjson: %s is not an integer", number_literal(i)), x.selector}
+	}
+	return r.Num(), nil
+}
+
+func (x Value) out_of_range(t string) error {
+	return &selector_error{fmt.Errorf("xjson: %s is out of range for %s", number_literal(x.inner), t), x.selector}
+}
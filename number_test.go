@@ -0,0 +1,241 @@
+package xjson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestParse_numbers(t *testing.T) {
+	var tests = []struct {
+		in    string
+		inner interface{}
+	}{
+		{`1`, int64(1)},
+		{`-0`, int64(0)},
+		{`9007199254740993`, int64(9007199254740993)},
+		{`18446744073709551615`, json.Number("18446744073709551615")},
+		{`0.1`, 0.1},
+		{`1.5e3`, 1500.0},
+		{`9007199254740993.0`, json.Number("9007199254740993.0")},
+		{`0.10000000000000000001`, json.Number("0.10000000000000000001")},
+		{`1e400`, json.Number("1e400")},
+		{`1e-400`, json.Number("1e-400")},
+		{`0e-400`, 0.0},
+	}
+
+	for _, test := range tests {
+		v := Parse([]byte(test.in))
+		if v.err != nil {
+			t.Errorf("%s: unexpected error: %s", test.in, v.err)
+			continue
+		}
+		if v.inner != test.inner {
+			t.Errorf("%s: expected %T(%v), got %T(%v)", test.in, test.inner, test.inner, v.inner, v.inner)
+		}
+		if _, ok := test.inner.(json.Number); ok {
+			if b, _ := v.MarshalJSON(); string(b) != test.in {
+				t.Errorf("%s: round trip produced %s", test.in, b)
+			}
+		}
+	}
+}
+
+func TestValueOf_numbers(t *testing.T) {
+	if v := ValueOf(uint64(math.MaxUint64)); v.MustNumber() != "18446744073709551615" {
+		t.Errorf("expected MaxUint64 to be kept, got %s", v.MustNumber())
+	}
+	if v := ValueOf(json.Number("12")); v.inner != int64(12) {
+		t.Errorf("expected json.Number to be converted, got %T", v.inner)
+	}
+	if v := ValueOf(json.Number("1x")); v.Kind() != Error {
+		t.Errorf("expected an error for a malformed json.Number")
+	}
+}
+
+func TestValue_BigInt(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+		err string
+	}{
+		{`12`, `12`, ``},
+		{`18446744073709551617`, `18446744073709551617`, ``},
+		{`1e3`, `1000`, ``},
+		{`1.5`, ``, `xjson: 1.5 is not an integer (at: $root, line 1 col 1)`},
+		{`"1"`, ``, `xjson: string is not a json number (at: $root, line 1 col 1)`},
+	}
+
+	for _, test := range tests {
+		i, err := Parse([]byte(test.in)).BigInt()
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.in, test.err, err)
+			}
+			continue
+		}
+		if err != nil || i.String() != test.out {
+			t.Errorf("%s: expected %s, got %v (%v)", test.in, test.out, i, err)
+		}
+	}
+}
+
+func TestValue_Decimal(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+	}{
+		{`12`, `12`},
+		{`0.1`, `0.1`},
+		{`1.5e-3`, `0.0015`},
+		{`-2.50`, `-2.5`},
+		{`0.10000000000000000001`, `0.10000000000000000001`},
+		{`12345678901234567890.5`, `12345678901234567890.5`},
+	}
+
+	for _, test := range tests {
+		if out := Parse([]byte(test.in)).MustDecimal(); out != test.out {
+			t.Errorf("%s: expected %s, got %s", test.in, test.out, out)
+		}
+	}
+
+	f := Parse([]byte(`0.10000000000000000001`)).MustBigFloat()
+	if f.Text('g', 21) != "0.10000000000000000001" {
+		t.Errorf("BigFloat lost digits: %s", f.Text('g', 21))
+	}
+}
+
+func TestEqual_bigNumbers(t *testing.T) {
+	a := Parse([]byte(`[18446744073709551616, 0.10000000000000000001]`))
+	b := Parse([]byte(`[1.8446744073709551616e19, 0.1]`))
+
+	if !a.GetIndex(0).Equal(b.GetIndex(0)) || a.GetIndex(0).Hash() != b.GetIndex(0).Hash() {
+		t.Errorf("expected %v and %v to be equal", a.GetIndex(0).inner, b.GetIndex(0).inner)
+	}
+	if a.GetIndex(1).Equal(b.GetIndex(1)) {
+		t.Errorf("expected %v and %v to differ", a.GetIndex(1).inner, b.GetIndex(1).inner)
+	}
+
+	var pairs = [][2]string{
+		{`9007199254740993`, `9007199254740993.0`},
+		{`18446744073709551616`, `18446744073709551616.00`},
+		{`0.5`, `5e-1`},
+		{`0.10000000000000000001`, `1.0000000000000000001e-1`},
+		{`-0`, `0.0e5`},
+	}
+	for _, pair := range pairs {
+		x, y := Parse([]byte(pair[0])), Parse([]byte(pair[1]))
+		if !x.Equal(y) {
+			t.Errorf("expected %s and %s to be equal", pair[0], pair[1])
+		}
+		if x.Hash() != y.Hash() {
+			t.Errorf("expected %s and %s to hash the same", pair[0], pair[1])
+		}
+	}
+}
+
+func TestValue_strictIntegers(t *testing.T) {
+	var tests = []struct {
+		in  string
+		get func(v Value) (interface{}, error)
+		out interface{}
+		err string
+	}{
+		{`3`, func(v Value) (interface{}, error) { return v.Int64() }, int64(3), ``},
+		{`3.0`, func(v Value) (interface{}, error) { return v.Int64() }, int64(3), ``},
+		{`3.9`, func(v Value) (interface{}, error) { return v.Int64() }, nil, `xjson: 3.9 is not an integer (at: $root, line 1 col 1)`},
+		{`-1`, func(v Value) (interface{}, error) { return v.Uint64() }, nil, `xjson: -1 is out of range for uint64 (at: $root, line 1 col 1)`},
+		{`18446744073709551615`, func(v Value) (interface{}, error) { return v.Uint64() }, uint64(math.MaxUint64), ``},
+		{`18446744073709551616`, func(v Value) (interface{}, error) { return v.Uint64() }, nil, `xjson: 18446744073709551616 is out of range for uint64 (at: $root, line 1 col 1)`},
+		{`9223372036854775808`, func(v Value) (interface{}, error) { return v.Int64() }, nil, `xjson: 9223372036854775808 is out of range for int64 (at: $root, line 1 col 1)`},
+		{`2147483647`, func(v Value) (interface{}, error) { return v.Int32() }, int32(math.MaxInt32), ``},
+		{`2147483648`, func(v Value) (interface{}, error) { return v.Int32() }, nil, `xjson: 2147483648 is out of range for int32 (at: $root, line 1 col 1)`},
+		{`-32769`, func(v Value) (interface{}, error) { return v.Int16() }, nil, `xjson: -32769 is out of range for int16 (at: $root, line 1 col 1)`},
+		{`4294967296`, func(v Value) (interface{}, error) { return v.Uint32() }, nil, `xjson: 4294967296 is out of range for uint32 (at: $root, line 1 col 1)`},
+		{`1e2`, func(v Value) (interface{}, error) { return v.Uint8() }, uint8(100), ``},
+		{`"1"`, func(v Value) (interface{}, error) { return v.Int() }, nil, `xjson: string is not a json number (at: $root, line 1 col 1)`},
+	}
+
+	for _, test := range tests {
+		out, err := test.get(Parse([]byte(test.in)))
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.in, test.err, err)
+			}
+			continue
+		}
+		if err != nil || out != test.out {
+			t.Errorf("%s: expected %v, got %v (%v)", test.in, test.out, out, err)
+		}
+	}
+}
+
+func TestValue_Float64(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out float64
+		err string
+	}{
+		{`1.5`, 1.5, ``},
+		{`3`, 3, ``},
+		{`0.10000000000000000001`, 0.1, ``},
+		{`1e-400`, 0, ``},
+		{`1e400`, 0, `xjson: 1e400 is out of range for float64 (at: $root, line 1 col 1)`},
+		{`-1e400`, 0, `xjson: -1e400 is out of range for float64 (at: $root, line 1 col 1)`},
+		{`"1"`, 0, `xjson: string is not a json number (at: $root, line 1 col 1)`},
+	}
+
+	for _, test := range tests {
+		out, err := Parse([]byte(test.in)).Float64()
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.in, test.err, err)
+			}
+			continue
+		}
+		if err != nil || out != test.out {
+			t.Errorf("%s: expected %v, got %v (%v)", test.in, test.out, out, err)
+		}
+	}
+
+	var f float64
+	err := Parse([]byte(`1e400`)).Unwrap(&f)
+	if err == nil || err.Error() != `$root: json number 1e400 overflows float64` {
+		t.Errorf("expected Unwrap to report the overflow, got %v", err)
+	}
+}
+
+func TestValue_hugeExponents(t *testing.T) {
+	const err = `xjson: 1e1000000000 cannot be represented exactly (at: $root, line 1 col 1)`
+
+	v := Parse([]byte(`1e1000000000`))
+	if v.inner != json.Number("1e1000000000") {
+		t.Fatalf("expected the literal to be kept, got %T(%v)", v.inner, v.inner)
+	}
+	if _, e := v.Int64(); e == nil || e.Error() != err {
+		t.Errorf("Int64: expected error %q, got %v", err, e)
+	}
+	if _, e := v.BigInt(); e == nil || e.Error() != err {
+		t.Errorf("BigInt: expected error %q, got %v", err, e)
+	}
+	if _, e := v.Decimal(); e == nil || e.Error() != err {
+		t.Errorf("Decimal: expected error %q, got %v", err, e)
+	}
+	if _, e := v.Coerce().Int(); e == nil {
+		t.Errorf("Coerce: expected an error")
+	}
+
+	w := Parse([]byte(`1e999999999`))
+	if v.Equal(w) || !v.Equal(Parse([]byte(`1e1000000000`))) {
+		t.Errorf("expected huge exponents to only equal the same literal")
+	}
+	if v.Hash() != Parse([]byte(`1e1000000000`)).Hash() {
+		t.Errorf("expected equal huge exponents to hash the same")
+	}
+	if compare_numbers(v.inner, int64(1)) != 1 || compare_numbers(int64(1), v.inner) != -1 {
+		t.Errorf("expected 1e1000000000 to be larger than 1")
+	}
+	if Parse([]byte(`1.00000000000000000001e-1000000000`)).Kind() != Number {
+		t.Errorf("expected a tiny number to parse")
+	}
+}
@@ -0,0 +1,128 @@
+package xjson
+
+import (
+	"errors"
+)
+
+var (
+	err_key_not_found      = errors.New("xjson: key not found")
+	err_index_out_of_range = errors.New("xjson: index out of range")
+)
+
+// IsMissing reports whether x was looked up by a key or index that doesn't
+// exist. Looking up anything inside a missing value or null is missing too.
+func (x Value) IsMissing() bool {
+	err := x.err
+	if e, ok := err.(*selector_error); ok {
+		err = e.err
+	}
+	if e, ok := err.(*type_conflict); ok {
+		// a key or index was looked up in null
+		return e.value == nil
+	}
+	return err == err_key_not_found || err == err_index_out_of_range
+}
+
+// IsNull reports whether x is an explicit json null.
+func (x Value) IsNull() bool {
+	return x.err == nil && x.inner == nil
+}
+
+// Exists reports whether x holds a value, null included.
+func (x Value) Exists() bool {
+	return x.err == nil
+}
+
+// use_default reports whether an ...Or accessor should return its default
+// without looking at x.
+func (x Value) use_default() bool {
+	return x.IsMissing() || x.IsNull()
+}
+
+// report_error passes an error swallowed by an ...Or accessor to the
+// callbacks given to it.
+func report_error(err error, on_error []func(error)) {
+	for _, fn := range on_error {
+		fn(err)
+	}
+}
+
+// BoolOr returns x as a bool, or def when x is missing or null. Other
+// errors, like type conflicts or syntax errors, also return def; they are
+// passed to the on_error callbacks so they don't go unnoticed.
+func (x Value) BoolOr(def bool, on_error ...func(error)) bool {
+	if x.use_default() {
+		return def
+	}
+	v, err := x.Bool()
+	if err != nil {
+		report_error(err, on_error)
+		return def
+	}
+	return v
+}
+
+// IntOr is like BoolOr for int.
+func (x Value) IntOr(def int, on_error ...func(error)) int {
+	if x.use_default() {
+		return def
+	}
+	v, err := x.Int()
+	if err != nil {
+		report_error(err, on_error)
+		return def
+	}
+	return v
+}
+
+// Int64Or is like BoolOr for int64.
+func (x Value) Int64Or(def int64, on_error ...func(error)) int64 {
+	if x.use_default() {
+		return def
+	}
+	v, err := x.Int64()
+	if err != nil {
+		report_error(err, on_error)
+		return def
+	}
+	return v
+}
+
+// Uint64Or is like BoolOr for uint64.
+func (x Value) Uint64Or(def uint64, on_error ...func(error)) uint64 {
+	if x.use_default() {
+		return def
+	}
+	v, err := x.Uint64()
+	if err != nil {
+		report_error(err, on_error)
+		return def
+	}
+	return v
+}
+
+// Float64Or is like BoolOr for float64.
+func (x Value) Float64Or(def float64, on_error ...func(error)) float64 {
+	if x.use_default() {
+		return def
+	}
+	v, err := x.Float64()
+	if err != nil {
+		report_error(err, on_error)
+		return def
+	}
+	return v
+}
+
+// StringOr is like BoolOr for string.
+func (x Value) StringOr(def string, on_error ...func(error)) string {
+	if x.use_default() {
+		return def
+	}
+	v, err := x.String()
+	if err != nil {
+		report_error(err, on_error)
+		return def
+	}
+	return v
+}
@@ -0,0 +1,81 @@
+package xjson
+
+import "testing"
+
+func TestValue_IsMissing(t *testing.T) {
+	v := Parse([]byte(`{"a": null, "b": "x", "c": [1]}`))
+
+	var tests = []struct {
+		value   Value
+		missing bool
+		null    bool
+		exists  bool
+	}{
+		{v.Get("a"), false, true, true},
+		{v.Get("b"), false, false, true},
+		{v.Get("z"), true, false, false},
+		{v.Get("z").Get("y"), true, false, false},
+		{v.Get("a").Get("y"), true, false, false},
+		{v.Get("a").GetIndex(0).Get("y"), true, false, false},
+		{ValueOf(nil).Get("y"), true, false, false},
+		{v.GetPath("c", 3), true, false, false},
+		{v.Get("b").Get("y"), false, false, false},
+		{Parse([]byte(`{`)), false, false, false},
+	}
+
+	for i, test := range tests {
+		if test.value.IsMissing() != test.missing || test.value.IsNull() != test.null || test.value.Exists() != test.exists {
+			t.Errorf("%d: expected missing=%v null=%v exists=%v, got %v %v %v", i,
+				test.missing, test.null, test.exists,
+				test.value.IsMissing(), test.value.IsNull(), test.value.Exists())
+		}
+	}
+
+	// lookups in null are still errors
+	_, err := v.Get("a").Get("y").Interface()
+	if err == nil || err.Error() != `xjson: <nil> is not a json object (at: $root.a, line 1 col 7)` {
+		t.Errorf("unexpected error for a lookup in null: %v", err)
+	}
+	if s := v.Get("a").Get("y").StringOr("def"); s != "def" {
+		t.Errorf("expected the default for a lookup in null, got %q", s)
+	}
+}
+
+func TestValue_StringOr(t *testing.T) {
+	var errs []string
+	report := func(err error) { errs = append(errs, err.Error()) }
+
+	v := Parse([]byte(`{"a": null, "b": "x", "n": 7}`))
+
+	if s := v.Get("a").StringOr("def", report); s != "def" {
+		t.Errorf("expected the default for null, got %q", s)
+	}
+	if s := v.Get("z").StringOr("def", report); s != "def" {
+		t.Errorf("expected the default for a missing key, got %q", s)
+	}
+	if s := v.Get("b").StringOr("def", report); s != "x" {
+		t.Errorf("expected the value, got %q", s)
+	}
+	if s := v.Get("n").StringOr("def", report); s != "def" {
+		t.Errorf("expected the default for a type conflict, got %q", s)
+	}
+	if n := v.Get("n").Int64Or(1, report); n != 7 {
+		t.Errorf("expected the value, got %d", n)
+	}
+	if n := v.Get("b").Int64Or(1, report); n != 1 {
+		t.Errorf("expected the default for a type conflict, got %d", n)
+	}
+
+	var expected = []string{
+		`xjson: int64 is not a json string (at: $root.n, line 1 col 28)`,
+		`xjson: string is not a json number (at: $root.b, line 1 col 18)`,
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d reported errors, got %q", len(expected), errs)
+	}
+	for i := range errs {
+		if errs[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], errs[i])
+		}
+	}
+}
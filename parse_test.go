@@ -0,0 +1,149 @@
+package xjson
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParse_trailing(t *testing.T) {
+	var tests = []struct {
+		in  string
+		err string
+	}{
+		{`{"a":1}`, ``},
+		{` {"a":1} `, ``},
+		{`{"a":1} garbage`, `xjson: unexpected byte 'g', expected end of input (line 1 col 9)`},
+		{`1 2`, `xjson: unexpected byte '2', expected end of input (line 1 col 3)`},
+		{``, `xjson: unexpected end of input, expected value (line 1 col 1)`},
+	}
+
+	for _, test := range tests {
+		_, err := Parse([]byte(test.in)).Interface()
+
+		if test.err == "" && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.in, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+}
+
+func TestParse_depth(t *testing.T) {
+	deep := bytes.Repeat([]byte{'['}, 5<<20)
+
+	_, err := Parse(deep).Interface()
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Fatalf("expected a *SyntaxError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "expected at most 10000 nested containers") ||
+		!strings.Contains(err.Error(), "line 1 col 10001") {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	err = Stream(bytes.NewReader(deep), []string{`$root`}, func(Value) error { return nil })
+	if _, ok := err.(*SyntaxError); !ok {
+		t.Errorf("expected Stream to fail with a *SyntaxError, got %v", err)
+	}
+
+	ok := strings.Repeat(`[`, max_depth) + strings.Repeat(`]`, max_depth)
+	if _, err := Parse([]byte(ok)).Interface(); err != nil {
+		t.Errorf("unexpected error at the maximum depth: %s", err)
+	}
+}
+
+func TestParseMany(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out []string
+	}{
+		{``, nil},
+		{`{"a":1}{"a":2} [3] 4 "5"`, []string{`{"a":1}`, `{"a":2}`, `[3]`, `4`, `"5"`}},
+		{"\x1e{\"a\":1}\n\x1e[2]\n", []string{`{"a":1}`, `[2]`}},
+		{"\x1e{\"a\":\n\x1e[2]\n\x1e\n", []string{`(xjson: unexpected end of input, expected value (at: $root.a, line 2 col 1))`, `[2]`}},
+		{`1 [2,`, []string{`1`, `(xjson: unexpected end of input, expected value (at: $root[1], line 1 col 6))`}},
+	}
+
+	for _, test := range tests {
+		var out []string
+		for _, v := range ParseMany([]byte(test.in)) {
+			out = append(out, describe_value(v))
+		}
+
+		if len(out) != len(test.out) {
+			t.Errorf("%q:\n  expected %q\n  got      %q", test.in, test.out, out)
+			continue
+		}
+		for i := range out {
+			if out[i] != test.out[i] {
+				t.Errorf("%q:\n  expected %q\n  got      %q", test.in, test.out, out)
+				break
+			}
+		}
+	}
+}
+
+func TestParseMany_positions(t *testing.T) {
+	values := ParseMany([]byte("\n\x1e1\n\x1e\n  [2]\n\x1e{\"a\":\n 3}\n"))
+	if len(values) != 3 {
+		t.Fatalf("expected 3 values, got %d", len(values))
+	}
+
+	var tests = []struct {
+		v     Value
+		start Position
+	}{
+		{values[0], Position{Offset: 2, Line: 2, Column: 2}},
+		{values[1], Position{Offset: 8, Line: 4, Column: 3}},
+		{values[2].Get("a"), Position{Offset: 20, Line: 6, Column: 2}},
+	}
+	for _, test := range tests {
+		if start, _ := test.v.Span(); start != test.start {
+			t.Errorf("%s: expected %s at offset %d, got %s at offset %d",
+				describe_value(test.v), test.start, test.start.Offset, start, start.Offset)
+		}
+	}
+}
+
+// TestParse_lexemes pins the decoder to the lexical rules in
+// internal/jsontext. The exp scanner runs the same inputs.
+func TestParse_lexemes(t *testing.T) {
+	for _, test := range read_lexemes(t, "internal/jsontext/testdata/lexemes.txt") {
+		_, err := Parse([]byte(test.in)).Interface()
+		if test.ok && err != nil {
+			t.Errorf("%q: unexpected error: %s", test.in, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%q: expected an error", test.in)
+		}
+	}
+}
+
+type lexeme_test struct {
+	in string
+	ok bool
+}
+
+// read_lexemes reads lines of `y "input"` and `n "input"`.
+func read_lexemes(t *testing.T, name string) []lexeme_test {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests []lexeme_test
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		in, err := strconv.Unquote(line[2:])
+		if err != nil || (line[0] != 'y' && line[0] != 'n') {
+			t.Fatalf("%s: malformed line %q", name, line)
+		}
+		tests = append(tests, lexeme_test{in, line[0] == 'y'})
+	}
+	return tests
+}
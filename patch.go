@@ -0,0 +1,325 @@
+package xjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PatchError reports the JSON Patch operation that could not be applied.
+type PatchError struct {
+	Index    int
+	Op       string
+	Selector Selector
+	Err      error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("xjson: patch operation %d (%s) failed at %s: %s", e.Index, e.Op, e.Selector, e.Err)
+}
+
+// ApplyPatch applies a RFC 6902 JSON Patch to doc. The patch is applied
+// atomically; on failure the returned Value holds the *PatchError.
+func ApplyPatch(doc Value, patch Value) (Value, error) {
+	if _, err := doc.Interface(); err != nil {
+		return doc, err
+	}
+
+	ops, err := patch.Array()
+	if err != nil {
+		return Value{nil, err, doc.Selector()}, err
+	}
+
+	for i := range ops {
+		doc, err = apply_patch_op(doc, patch.GetIndex(i))
+		if err != nil {
+			err = patch_error(i, patch.GetIndex(i), err)
+			return Value{nil, err, doc.Selector()}, err
+		}
+	}
+
+	return doc, nil
+}
+
+func apply_patch_op(doc Value, op Value) (Value, error) {
+	name, err := op.Get("op").String()
+	if err != nil {
+		return doc, err
+	}
+
+	path, err := op.Get("path").String()
+	if err != nil {
+		return doc, err
+	}
+
+	switch name {
+	case "add":
+		v := op.Get("value")
+		if v.err != nil {
+			return doc, v.err
+		}
+		return patch_add(doc, path, v)
+
+	case "remove":
+		return patch_remove(doc, path)
+
+	case "replace":
+		v := op.Get("value")
+		if v.err != nil {
+			return doc, v.err
+		}
+		if target := doc.GetPointer(path); target.err != nil {
+			return doc, target.err
+		}
+		parts, err := pointer_path(doc, path)
+		if err != nil {
+			return doc, err
+		}
+		return patch_result(doc.Set(path_selector_from(doc.Selector(), parts), v))
+
+	case "move", "copy":
+		from, err := op.Get("from").String()
+		if err != nil {
+			return doc, err
+		}
+		v := doc.GetPointer(from)
+		if v.err != nil {
+			return doc, v.err
+		}
+		if name == "copy" {
+			return patch_add(doc, path, v)
+		}
+		if from == path {
+			return doc, nil
+		}
+		if strings.HasPrefix(path, from+"/") {
+			return doc, &selector_error{fmt.Errorf("xjson: cannot move a value into one of its children"), v.Selector()}
+		}
+		doc, err = patch_remove(doc, from)
+		if err != nil {
+			return doc, err
+		}
+		return patch_add(doc, path, v)
+
+	case "test":
+		v := op.Get("value")
+		if v.err != nil {
+			return doc, v.err
+		}
+		target := doc.GetPointer(path)
+		if target.err != nil {
+			return doc, target.err
+		}
+		if !equal_inner(target.inner, v.inner) {
+			return doc, &selector_error{fmt.Errorf("xjson: test failed"), target.Selector()}
+		}
+		return doc, nil
+
+	default:
+		return doc, &selector_error{fmt.Errorf("xjson: unknown operation %q", name), op.Get("op").Selector()}
+	}
+}
+
+func patch_add(doc Value, path string, v Value) (Value, error) {
+	tokens, err := parse_pointer(path)
+	if err != nil {
+		return doc, err
+	}
+	if len(tokens) == 0 {
+		return ValueOf(v), nil
+	}
+
+	var (
+		last   = tokens[len(tokens)-1]
+		parent = doc.GetPointer(path[:strings.LastIndex(path, "/")])
+	)
+	if parent.err != nil {
+		return doc, parent.err
+	}
+
+	parts, err := pointer_path(doc, path[:strings.LastIndex(path, "/")])
+	if err != nil {
+		return doc, err
+	}
+
+	if a, ok := parent.inner.([]interface{}); ok {
+		idx := len(a)
+		if last != "-" {
+			idx, err = parse_pointer_index(last)
+			if err != nil {
+				return doc, &selector_error{err, &key_selector{nil, last, parent.Selector()}}
+			}
+		}
+		return patch_result(doc.Insert(path_selector_from(doc.Selector(), parts), idx, v))
+	}
+
+	return patch_result(doc.Set(path_selector_from(doc.Selector(), append(parts, last)), v))
+}
+
+func patch_remove(doc Value, path string) (Value, error) {
+	if target := doc.GetPointer(path); target.err != nil {
+		return doc, target.err
+	}
+	parts, err := pointer_path(doc, path)
+	if err != nil {
+		return doc, err
+	}
+	return patch_result(doc.Delete(path_selector_from(doc.Selector(), parts)))
+}
+
+// pointer_path converts a JSON Pointer into GetPath() style parts relative
+// to x; path_selector_from(x.Selector(), parts) gives their location. Tokens are read as indexes where they refer into an array of x.
+func pointer_path(x Value, ptr string) ([]interface{}, error) {
+	tokens, err := parse_pointer(ptr)
+	if err != nil {
+		return nil, &selector_error{err, x.Selector()}
+	}
+
+	parts := make([]interface{}, len(tokens))
+	for i, token := range tokens {
+		a, is_array := x.inner.([]interface{})
+		if !is_array {
+			parts[i], x = token, x.Get(token)
+			continue
+		}
+
+		idx := len(a)
+		if token != "-" {
+			idx, err = parse_pointer_index(token)
+			if err != nil {
+				return nil, &selector_error{err, &key_selector{nil, token, x.Selector()}}
+			}
+		}
+		parts[i], x = idx, x.GetIndex(idx)
+	}
+
+	return parts, nil
+}
+
+func patch_result(x Value) (Value, error) {
+	if x.err != nil {
+		return x, x.err
+	}
+	return x, nil
+}
+
+func patch_error(idx int, op Value, err error) error {
+	var (
+		name, _          = op.Get("op").String()
+		sel     Selector = op.Selector()
+	)
+
+	if e, ok := err.(*selector_error); ok {
+		err, sel = e.err, e.selector
+	}
+
+	return &PatchError{idx, name, sel, err}
+}
+
+// Diff returns a RFC 6902 JSON Patch that transforms a into b.
+func Diff(a, b Value) Value {
+	if _, err := a.Interface(); err != nil {
+		return a
+	}
+	if _, err := b.Interface(); err != nil {
+		return b
+	}
+
+	ops := []interface{}{}
+	diff_inner(&ops, "", a.inner, b.inner)
+	return ValueOf(ops)
+}
+
+func diff_inner(ops *[]interface{}, path string, a, b interface{}) {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		if y, ok := b.(map[string]interface{}); ok {
+			diff_objects(ops, path, x, y)
+			return
+		}
+	case []interface{}:
+		if y, ok := b.([]interface{}); ok {
+			diff_arrays(ops, path, x, y)
+			return
+		}
+	}
+
+	if !equal_inner(a, b) {
+		*ops = append(*ops, patch_op("replace", path, b))
+	}
+}
+
+func diff_objects(ops *[]interface{}, path string, a, b map[string]interface{}) {
+	for _, key := range sorted_keys(a) {
+		if _, found := b[key]; !found {
+			*ops = append(*ops, map[string]interface{}{
+				"op":   "remove",
+				"path": path + "/" + escape_pointer_token(key),
+			})
+		}
+	}
+
+	for _, key := range sorted_keys(b) {
+		p := path + "/" + escape_pointer_token(key)
+		if av, found := a[key]; found {
+			diff_inner(ops, p, av, b[key])
+		} else {
+			*ops = append(*ops, patch_op("add", p, b[key]))
+		}
+	}
+}
+
+func diff_arrays(ops *[]interface{}, path string, a, b []interface{}) {
+	// strip the common prefix and suffix
+	pre := 0
+	for pre < len(a) && pre < len(b) && equal_inner(a[pre], b[pre]) {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && equal_inner(a[len(a)-1-suf], b[len(b)-1-suf]) {
+		suf++
+	}
+	a, b = a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if equal_inner(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// idx tracks the position in the array as it is being patched
+	var i, j, idx = 0, 0, pre
+	for i < len(a) || j < len(b) {
+		p := path + "/" + strconv.Itoa(idx)
+
+		switch {
+		case i < len(a) && j < len(b) && equal_inner(a[i], b[j]):
+			i, j, idx = i+1, j+1, idx+1
+		case i < len(a) && j < len(b) && lcs[i+1][j+1] == lcs[i][j]:
+			diff_inner(ops, p, a[i], b[j])
+			i, j, idx = i+1, j+1, idx+1
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			*ops = append(*ops, patch_op("add", p, b[j]))
+			j, idx = j+1, idx+1
+		default:
+			*ops = append(*ops, map[string]interface{}{"op": "remove", "path": p})
+			i++
+		}
+	}
+}
+
+func patch_op(op, path string, v interface{}) map[string]interface{} {
+	return map[string]interface{}{"op": op, "path": path, "value": v}
+}
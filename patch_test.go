@@ -0,0 +1,123 @@
+package xjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	var tests = []struct {
+		doc   string
+		patch string
+		out   string
+		err   string
+	}{
+		// RFC 6902, appendix A
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, ``},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, ``},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, ``},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, ``},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, ``},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, ``},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, ``},
+		{`{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, ``},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``,
+			`xjson: patch operation 0 (test) failed at $root.baz: xjson: test failed`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`, ``},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``,
+			`xjson: patch operation 0 (add) failed at $root.baz: xjson: key not found`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, ``},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, ``},
+		{`{"foo":"bar"}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"baz":"bar","foo":"bar"}`, ``},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`, ``},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, ``,
+			`xjson: patch operation 0 (move) failed at $root.foo: xjson: cannot move a value into one of its children`},
+		{`{"foo":[1]}`, `[{"op":"remove","path":"/foo/0"},{"op":"replace","path":"/foo/1","value":2}]`, ``,
+			`xjson: patch operation 1 (replace) failed at $root.foo[1]: xjson: index out of range`},
+		{`{"foo":[1]}`, `[{"op":"add","path":"/foo/01","value":2}]`, ``,
+			`xjson: patch operation 0 (add) failed at $root.foo["01"]: xjson: invalid array index "01"`},
+		{`{"foo":[1]}`, `[{"op":"frobnicate","path":"/foo"}]`, ``,
+			`xjson: patch operation 0 (frobnicate) failed at $root[0].op: xjson: unknown operation "frobnicate"`},
+	}
+
+	for _, test := range tests {
+		v, err := ApplyPatch(Parse([]byte(test.doc)), Parse([]byte(test.patch)))
+
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.patch, test.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.patch, err)
+			continue
+		}
+
+		b, _ := json.Marshal(&v)
+		if string(b) != test.out {
+			t.Errorf("%s:\n  expected %s\n  got      %s", test.patch, test.out, b)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var tests = []struct {
+		a, b  string
+		patch string
+	}{
+		{`{"a":1}`, `{"a":1.0}`, `[]`},
+		{`{"a":1,"b":2}`, `{"a":1,"c":3}`, `[{"op":"remove","path":"/b"},{"op":"add","path":"/c","value":3}]`},
+		{`{"a":{"b":[1,2,3]}}`, `{"a":{"b":[1,3]}}`, `[{"op":"remove","path":"/a/b/1"}]`},
+		{`[1,2,3,4,5]`, `[0,1,2,4,5,6]`, `[{"op":"add","path":"/0","value":0},{"op":"remove","path":"/3"},{"op":"add","path":"/5","value":6}]`},
+		{`[{"a":1},{"b":2}]`, `[{"a":1},{"b":3}]`, `[{"op":"replace","path":"/1/b","value":3}]`},
+		{`["a","b","c"]`, `["x","y","c"]`, `[{"op":"replace","path":"/0","value":"x"},{"op":"replace","path":"/1","value":"y"}]`},
+		{`{"a/b":1}`, `{"a/b":2}`, `[{"op":"replace","path":"/a~1b","value":2}]`},
+		{`{"a":1}`, `[1]`, `[{"op":"replace","path":"","value":[1]}]`},
+	}
+
+	for _, test := range tests {
+		var (
+			a     = Parse([]byte(test.a))
+			b     = Parse([]byte(test.b))
+			patch = Diff(a, b)
+		)
+
+		buf, _ := json.Marshal(&patch)
+		if string(buf) != test.patch {
+			t.Errorf("Diff(%s, %s):\n  expected %s\n  got      %s", test.a, test.b, test.patch, buf)
+		}
+
+		c, err := ApplyPatch(a, patch)
+		if err != nil {
+			t.Errorf("Diff(%s, %s): patch failed: %s", test.a, test.b, err)
+			continue
+		}
+		if !equal_inner(c.inner, b.inner) {
+			t.Errorf("Diff(%s, %s): patch produced %v", test.a, test.b, c.inner)
+		}
+	}
+}
+
+func TestApplyPatch_subdocument(t *testing.T) {
+	doc := Parse([]byte(`{"cfg":{"a":1,"b":[1,2]},"other":true}`)).Get("cfg")
+	patch := Parse([]byte(`[
+		{"op":"replace","path":"/a","value":2},
+		{"op":"add","path":"/b/0","value":0},
+		{"op":"remove","path":"/b/2"},
+		{"op":"add","path":"/c","value":"x"}
+	]`))
+
+	v, err := ApplyPatch(doc, patch)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b, _ := json.Marshal(&v); string(b) != `{"a":2,"b":[0,1],"c":"x"}` {
+		t.Errorf("unexpected result: %s", b)
+	}
+}
@@ -0,0 +1,115 @@
+package xjson
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GetPointer looks up the value referenced by a RFC 6901 JSON Pointer
+// (like "/people/1/first name") relative to x.
+func (x Value) GetPointer(ptr string) Value {
+	tokens, err := parse_pointer(ptr)
+	if err != nil {
+		err = &selector_error{err, x.Selector()}
+		return Value{nil, err, x.Selector()}
+	}
+
+	for _, token := range tokens {
+		a, is_array := x.inner.([]interface{})
+		if x.err != nil || !is_array {
+			x = x.Get(token)
+			continue
+		}
+
+		if token == "-" {
+			// refers to the (nonexistent) element after the last one
+			x = x.GetIndex(len(a))
+			continue
+		}
+
+		idx, err := parse_pointer_index(token)
+		if err != nil {
+			sel := &key_selector{err, token, x.selector}
+			return Value{nil, &selector_error{err, sel}, sel}
+		}
+		x = x.GetIndex(idx)
+	}
+
+	return x
+}
+
+func parse_pointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("xjson: invalid JSON pointer %q", ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		token, ok := unescape_pointer_token(token)
+		if !ok {
+			return nil, fmt.Errorf("xjson: invalid JSON pointer %q", ptr)
+		}
+		tokens[i] = token
+	}
+
+	return tokens, nil
+}
+
+func parse_pointer_index(token string) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("xjson: invalid array index %q", token)
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("xjson: invalid array index %q", token)
+		}
+	}
+
+	idx, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("xjson: invalid array index %q", token)
+	}
+	return idx, nil
+}
+
+func escape_pointer_token(token string) string {
+	if strings.IndexAny(token, "~/") < 0 {
+		return token
+	}
+	token = strings.Replace(token, "~", "~0", -1)
+	token = strings.Replace(token, "/", "~1", -1)
+	return token
+}
+
+func unescape_pointer_token(token string) (string, bool) {
+	if strings.IndexByte(token, '~') < 0 {
+		return token, true
+	}
+
+	var buf []byte
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if c != '~' {
+			buf = append(buf, c)
+			continue
+		}
+		if i+1 == len(token) {
+			return "", false
+		}
+		i++
+		switch token[i] {
+		case '0':
+			buf = append(buf, '~')
+		case '1':
+			buf = append(buf, '/')
+		default:
+			return "", false
+		}
+	}
+
+	return string(buf), true
+}
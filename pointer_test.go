@@ -0,0 +1,63 @@
+package xjson
+
+import (
+	"testing"
+)
+
+func TestValue_GetPointer(t *testing.T) {
+	const doc = `{"a~b":1,"c/d":2,"~1":3,"arr":[10,20],"":{"":4},"n":null}`
+
+	var tests = []struct {
+		ptr string
+		out string
+	}{
+		{``, `{"":{"":4},"arr":[10,20],"a~b":1,"c/d":2,"n":null,"~1":3}`},
+		{`/a~0b`, `1`},
+		{`/c~1d`, `2`},
+		{`/~01`, `3`},
+		{`/arr/0`, `10`},
+		{`/arr/1`, `20`},
+		{`//`, `4`},
+		{`/n`, `null`},
+
+		{`a`, `(xjson: invalid JSON pointer "a" (at: $root, line 1 col 1))`},
+		{`/a~2b`, `(xjson: invalid JSON pointer "/a~2b" (at: $root, line 1 col 1))`},
+		{`/a~`, `(xjson: invalid JSON pointer "/a~" (at: $root, line 1 col 1))`},
+		{`/arr/-`, `(xjson: index out of range (at: $root.arr[2], line 1 col 31))`},
+		{`/arr/01`, `(xjson: invalid array index "01" (at: $root.arr["01"], line 1 col 31))`},
+		{`/arr/-1`, `(xjson: invalid array index "-1" (at: $root.arr["-1"], line 1 col 31))`},
+		{`/arr/x`, `(xjson: invalid array index "x" (at: $root.arr.x, line 1 col 31))`},
+		{`/arr/`, `(xjson: invalid array index "" (at: $root.arr[""], line 1 col 31))`},
+		{`/missing/0`, `(xjson: key not found (at: $root.missing, line 1 col 1))`},
+		{`/a~0b/0`, `(xjson: int64 is not a json object (at: $root["a~b"], line 1 col 8))`},
+	}
+
+	v := Parse([]byte(doc))
+	for _, test := range tests {
+		if out := describe_value(v.GetPointer(test.ptr)); out != test.out {
+			t.Errorf("%q:\n  expected %s\n  got      %s", test.ptr, test.out, out)
+		}
+	}
+}
+
+func TestSelector_Pointer(t *testing.T) {
+	v := Parse([]byte(`{"a/b":[{"~":1}]}`))
+
+	var tests = []struct {
+		sel Selector
+		out string
+	}{
+		{v.Selector(), ``},
+		{v.Get("a/b").Selector(), `/a~1b`},
+		{v.GetPath("a/b", 0, "~").Selector(), `/a~1b/0/~0`},
+	}
+
+	for _, test := range tests {
+		if out := test.sel.Pointer(); out != test.out {
+			t.Errorf("%s: expected %q, got %q", test.sel, test.out, out)
+		}
+		if got := v.GetPointer(test.sel.Pointer()); !got.Equal(test.sel.Value()) {
+			t.Errorf("%s: pointer %q does not round trip", test.sel, test.sel.Pointer())
+		}
+	}
+}
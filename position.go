@@ -0,0 +1,115 @@
+package xjson
+
+import (
+	"fmt"
+)
+
+// Position is a location in the source of a parsed document. Line and
+// Column are 1-based, Column counts bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position refers to an actual source location.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("line %d col %d", p.Line, p.Column)
+}
+
+// position_at returns the position of offset in data.
+func position_at(data []byte, offset int) Position {
+	return advance(Position{0, 1, 1}, data[:offset])
+}
+
+// advance returns the position just past data when data starts at p.
+func advance(p Position, data []byte) Position {
+	p.Offset += len(data)
+	for _, c := range data {
+		if c == '\n' {
+			p.Line++
+			p.Column = 1
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
+
+// span records the source location of a parsed value and its children.
+type span struct {
+	start    Position
+	end      Position
+	elements []*span
+	members  map[string]*span
+}
+
+// Span returns the source location of x; end points just past the last
+// byte of the value. Values that were not parsed from source, or that were
+// derived through Set, MergePatch etc., return invalid positions.
+func (x Value) Span() (start, end Position) {
+	if s := find_span(x.selector, true); s != nil {
+		return s.start, s.end
+	}
+	return
+}
+
+// find_span looks up the span of the value at sel. Unless exact is set the
+// span of the closest parsed ancestor is returned when sel itself has none.
+func find_span(sel Selector, exact bool) *span {
+	var parts []interface{}
+
+	for {
+		switch s := sel.(type) {
+		case *index_selector:
+			parts = append(parts, s.idx)
+			sel = s.parent
+			continue
+		case *key_selector:
+			parts = append(parts, s.key)
+			sel = s.parent
+			continue
+		case *root_selector:
+			return walk_span(s.spans, parts, exact)
+		case *line_selector:
+			return walk_span(s.spans, parts, exact)
+		default:
+			return nil
+		}
+	}
+}
+
+// walk_span follows parts, which are in reverse order, from the root span.
+func walk_span(root *span, parts []interface{}, exact bool) *span {
+	if root == nil {
+		return nil
+	}
+
+	s := root
+	for i := len(parts) - 1; i >= 0; i-- {
+		var child *span
+
+		switch p := parts[i].(type) {
+		case string:
+			child = s.members[p]
+		case int:
+			if p >= 0 && p < len(s.elements) {
+				child = s.elements[p]
+			}
+		}
+
+		if child == nil {
+			if exact {
+				return nil
+			}
+			return s
+		}
+		s = child
+	}
+
+	return s
+}
@@ -0,0 +1,41 @@
+package xjson
+
+import (
+	"testing"
+)
+
+func TestSpan(t *testing.T) {
+	var js = "{\n  \"a\": [1, true],\n  \"b\": {\"c\": \"x\"}\n}"
+
+	var tests = []struct {
+		path       []interface{}
+		start, end Position
+	}{
+		{nil, Position{0, 1, 1}, Position{39, 4, 2}},
+		{[]interface{}{"a"}, Position{9, 2, 8}, Position{18, 2, 17}},
+		{[]interface{}{"a", 1}, Position{13, 2, 12}, Position{17, 2, 16}},
+		{[]interface{}{"b", "c"}, Position{33, 3, 14}, Position{36, 3, 17}},
+	}
+
+	v := Parse([]byte(js))
+	for _, test := range tests {
+		start, end := v.GetPath(test.path...).Span()
+		if start != test.start || end != test.end {
+			t.Errorf("%v: expected %v-%v, got %v-%v", test.path, test.start, test.end, start, end)
+		}
+	}
+
+	if start, _ := v.Set(v.Get("a").Selector(), 1).Get("b").Span(); start.IsValid() {
+		t.Errorf("updated values should not have a position, got %v", start)
+	}
+
+	values := ParseMany([]byte("\x1e1\n\x1e\"a\"\n"))
+	if start, _ := values[1].Span(); start != (Position{4, 2, 2}) {
+		t.Errorf("expected the second record to start at line 2 col 2, got %v", start)
+	}
+
+	_, err := v.GetPath("b", "c").Int64()
+	if err == nil || err.Error() != "xjson: string is not a json number (at: $root.b.c, line 3 col 14)" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,1122 @@
+package xjson
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Query evaluates a JSONPath expression (RFC 9535) against x. The `$`
+// identifier refers to x itself. Every returned Value carries the selector
+// of the location it was found at.
+func (x Value) Query(expr string) ([]Value, error) {
+	if x.err != nil {
+		return nil, x.err
+	}
+
+	q, err := parse_query(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	root := Value{x.inner, nil, x.Selector()}
+	return q.eval(root, root), nil
+}
+
+type jp_selector_kind uint8
+
+const (
+	jp_name jp_selector_kind = iota
+	jp_wildcard
+	jp_index
+	jp_slice
+	jp_filter
+)
+
+type jp_segment struct {
+	descendant bool
+	selectors  []jp_selector
+}
+
+type jp_selector struct {
+	kind   jp_selector_kind
+	name   string
+	index  int64
+	slice  [3]*int64
+	filter jp_expr
+}
+
+// jp_expr is one of jp_or, jp_and, jp_not, *jp_compare, *jp_literal,
+// *jp_query or *jp_call.
+type jp_expr interface{}
+
+type jp_or []jp_expr
+type jp_and []jp_expr
+type jp_not struct{ expr jp_expr }
+
+type jp_compare struct {
+	op   string
+	l, r jp_expr
+}
+
+type jp_literal struct {
+	value interface{}
+}
+
+type jp_query struct {
+	absolute bool
+	segments []jp_segment
+}
+
+type jp_call struct {
+	name string
+	fn   *jp_function
+	args []jp_expr
+	re   *regexp.Regexp
+}
+
+// jp_maybe is the result of a value expression; ok is false when the
+// expression selected nothing.
+type jp_maybe struct {
+	value interface{}
+	ok    bool
+}
+
+type jp_type uint8
+
+const (
+	jp_value_type jp_type = iota
+	jp_logical_type
+	jp_nodes_type
+)
+
+type jp_function struct {
+	params []jp_type
+	result jp_type
+	call   func(c *jp_call, args []interface{}) interface{}
+}
+
+var jp_functions map[string]*jp_function
+
+func init() {
+	jp_functions = map[string]*jp_function{
+		"length": {[]jp_type{jp_value_type}, jp_value_type, jp_length},
+		"count":  {[]jp_type{jp_nodes_type}, jp_value_type, jp_count},
+		"match":  {[]jp_type{jp_value_type, jp_value_type}, jp_logical_type, jp_match},
+		"search": {[]jp_type{jp_value_type, jp_value_type}, jp_logical_type, jp_search},
+		"value":  {[]jp_type{jp_nodes_type}, jp_value_type, jp_value},
+	}
+}
+
+func (q *jp_query) eval(root, cur Value) []Value {
+	nodes := []Value{cur}
+	if q.absolute {
+		nodes[0] = root
+	}
+
+	for _, seg := range q.segments {
+		var out []Value
+		for _, n := range nodes {
+			if seg.descendant {
+				out = seg.descend(root, n, out)
+			} else {
+				out = seg.apply(root, n, out)
+			}
+		}
+		nodes = out
+	}
+
+	return nodes
+}
+
+func (q *jp_query) is_singular() bool {
+	for _, seg := range q.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if k := seg.selectors[0].kind; k != jp_name && k != jp_index {
+			return false
+		}
+	}
+	return true
+}
+
+func (seg *jp_segment) apply(root, x Value, out []Value) []Value {
+	for i := range seg.selectors {
+		out = seg.selectors[i].apply(root, x, out)
+	}
+	return out
+}
+
+func (seg *jp_segment) descend(root, x Value, out []Value) []Value {
+	out = seg.apply(root, x, out)
+	for _, c := range jp_children(x) {
+		out = seg.descend(root, c, out)
+	}
+	return out
+}
+
+func (s *jp_selector) apply(root, x Value, out []Value) []Value {
+	switch s.kind {
+	case jp_name:
+		if o, ok := x.inner.(map[string]interface{}); ok {
+			if v, found := o[s.name]; found {
+				out = append(out, Value{v, nil, &key_selector{v, s.name, x.selector}})
+			}
+		}
+
+	case jp_wildcard:
+		out = append(out, jp_children(x)...)
+
+	case jp_index:
+		if a, ok := x.inner.([]interface{}); ok {
+			i := s.index
+			if i < 0 {
+				i += int64(len(a))
+			}
+			if 0 <= i && i < int64(len(a)) {
+				out = append(out, jp_element(x, a, int(i)))
+			}
+		}
+
+	case jp_slice:
+		if a, ok := x.inner.([]interface{}); ok {
+			out = s.apply_slice(x, a, out)
+		}
+
+	case jp_filter:
+		for _, c := range jp_children(x) {
+			if jp_eval_logical(s.filter, root, c) {
+				out = append(out, c)
+			}
+		}
+	}
+
+	return out
+}
+
+func (s *jp_selector) apply_slice(x Value, a []interface{}, out []Value) []Value {
+	var (
+		l     = int64(len(a))
+		step  = int64(1)
+		start int64
+		end   int64
+	)
+
+	if s.slice[2] != nil {
+		step = *s.slice[2]
+	}
+	if step == 0 {
+		return out
+	}
+
+	if step > 0 {
+		start, end = 0, l
+	} else {
+		start, end = l-1, -l-1
+	}
+	if s.slice[0] != nil {
+		start = *s.slice[0]
+	}
+	if s.slice[1] != nil {
+		end = *s.slice[1]
+	}
+
+	normalize := func(i int64) int64 {
+		if i >= 0 {
+			return i
+		}
+		return l + i
+	}
+	clamp := func(i, lo, hi int64) int64 {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	if step > 0 {
+		lower := clamp(normalize(start), 0, l)
+		upper := clamp(normalize(end), 0, l)
+		for i := lower; i < upper; i += step {
+			out = append(out, jp_element(x, a, int(i)))
+		}
+	} else {
+		upper := clamp(normalize(start), -1, l-1)
+		lower := clamp(normalize(end), -1, l-1)
+		for i := upper; lower < i; i += step {
+			out = append(out, jp_element(x, a, int(i)))
+		}
+	}
+
+	return out
+}
+
+func jp_element(x Value, a []interface{}, i int) Value {
+	return Value{a[i], nil, &index_selector{a[i], i, x.selector}}
+}
+
+func jp_children(x Value) []Value {
+	switch y := x.inner.(type) {
+	case []interface{}:
+		out := make([]Value, len(y))
+		for i := range y {
+			out[i] = jp_element(x, y, i)
+		}
+		return out
+	case map[string]interface{}:
+		keys := sorted_keys(y)
+		out := make([]Value, len(keys))
+		for i, key := range keys {
+			v := y[key]
+			out[i] = Value{v, nil, &key_selector{v, key, x.selector}}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func jp_eval_logical(e jp_expr, root, cur Value) bool {
+	switch y := e.(type) {
+	case jp_or:
+		for _, e := range y {
+			if jp_eval_logical(e, root, cur) {
+				return true
+			}
+		}
+		return false
+	case jp_and:
+		for _, e := range y {
+			if !jp_eval_logical(e, root, cur) {
+				return false
+			}
+		}
+		return true
+	case jp_not:
+		return !jp_eval_logical(y.expr, root, cur)
+	case *jp_compare:
+		return y.eval(root, cur)
+	case *jp_query:
+		return len(y.eval(root, cur)) > 0
+	case *jp_call:
+		switch r := y.eval(root, cur).(type) {
+		case bool:
+			return r
+		case []Value:
+			return len(r) > 0
+		}
+	}
+	panic("should not happen!")
+}
+
+func jp_eval_value(e jp_expr, root, cur Value) jp_maybe {
+	switch y := e.(type) {
+	case *jp_literal:
+		return jp_maybe{y.value, true}
+	case *jp_query:
+		nodes := y.eval(root, cur)
+		if len(nodes) == 1 {
+			return jp_maybe{nodes[0].inner, true}
+		}
+		return jp_maybe{}
+	case *jp_call:
+		return y.eval(root, cur).(jp_maybe)
+	}
+	panic("should not happen!")
+}
+
+func (c *jp_compare) eval(root, cur Value) bool {
+	var (
+		l = jp_eval_value(c.l, root, cur)
+		r = jp_eval_value(c.r, root, cur)
+	)
+
+	switch c.op {
+	case "==":
+		return jp_equal(l, r)
+	case "!=":
+		return !jp_equal(l, r)
+	case "<":
+		return jp_less(l, r)
+	case "<=":
+		return jp_less(l, r) || jp_equal(l, r)
+	case ">":
+		return jp_less(r, l)
+	case ">=":
+		return jp_less(r, l) || jp_equal(l, r)
+	}
+	panic("should not happen!")
+}
+
+func jp_equal(a, b jp_maybe) bool {
+	if !a.ok || !b.ok {
+		return a.ok == b.ok
+	}
+	return equal_inner(a.value, b.value)
+}
+
+func jp_less(a, b jp_maybe) bool {
+	if !a.ok || !b.ok {
+		return false
+	}
+	if is_number(a.value) && is_number(b.value) {
+		return compare_numbers(a.value, b.value) < 0
+	}
+	if x, ok := a.value.(string); ok {
+		if y, ok := b.value.(string); ok {
+			return x < y
+		}
+	}
+	return false
+}
+
+func (c *jp_call) eval(root, cur Value) interface{} {
+	args := make([]interface{}, len(c.args))
+	for i, arg := range c.args {
+		switch c.fn.params[i] {
+		case jp_value_type:
+			args[i] = jp_eval_value(arg, root, cur)
+		case jp_logical_type:
+			args[i] = jp_eval_logical(arg, root, cur)
+		case jp_nodes_type:
+			if q, ok := arg.(*jp_query); ok {
+				args[i] = q.eval(root, cur)
+			} else {
+				args[i] = arg.(*jp_call).eval(root, cur)
+			}
+		}
+	}
+	return c.fn.call(c, args)
+}
+
+func jp_length(c *jp_call, args []interface{}) interface{} {
+	v := args[0].(jp_maybe)
+	if !v.ok {
+		return jp_maybe{}
+	}
+	switch y := v.value.(type) {
+	case string:
+		return jp_maybe{int64(utf8.RuneCountInString(y)), true}
+	case []interface{}:
+		return jp_maybe{int64(len(y)), true}
+	case map[string]interface{}:
+		return jp_maybe{int64(len(y)), true}
+	default:
+		return jp_maybe{}
+	}
+}
+
+func jp_count(c *jp_call, args []interface{}) interface{} {
+	return jp_maybe{int64(len(args[0].([]Value))), true}
+}
+
+func jp_value(c *jp_call, args []interface{}) interface{} {
+	nodes := args[0].([]Value)
+	if len(nodes) == 1 {
+		return jp_maybe{nodes[0].inner, true}
+	}
+	return jp_maybe{}
+}
+
+func jp_match(c *jp_call, args []interface{}) interface{} {
+	return jp_regexp_call(c, args, true)
+}
+
+func jp_search(c *jp_call, args []interface{}) interface{} {
+	return jp_regexp_call(c, args, false)
+}
+
+func jp_regexp_call(c *jp_call, args []interface{}, full bool) interface{} {
+	var (
+		s, ok1  = args[0].(jp_maybe).value.(string)
+		re, ok2 = args[1].(jp_maybe).value.(string)
+	)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	r := c.re
+	if r == nil {
+		var err error
+		r, err = compile_iregexp(re, full)
+		if err != nil {
+			return false
+		}
+	}
+
+	return r.MatchString(s)
+}
+
+// compile_iregexp translates an I-Regexp (RFC 9485) into a Go regexp. The
+// only difference that matters is that `.` must not match \n or \r.
+func compile_iregexp(expr string, full bool) (*regexp.Regexp, error) {
+	var (
+		buf      strings.Builder
+		in_class bool
+	)
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\\' && i+1 < len(expr):
+			buf.WriteByte(c)
+			i++
+			buf.WriteByte(expr[i])
+			continue
+		case c == '[':
+			in_class = true
+		case c == ']':
+			in_class = false
+		case c == '.' && !in_class:
+			buf.WriteString(`[^\n\r]`)
+			continue
+		}
+		buf.WriteByte(c)
+	}
+
+	if full {
+		return regexp.Compile(`\A(?:` + buf.String() + `)\z`)
+	}
+	return regexp.Compile(buf.String())
+}
+
+type query_parser struct {
+	src string
+	pos int
+}
+
+type query_error struct {
+	expr string
+	msg  string
+	pos  int
+}
+
+func (e *query_error) Error() string {
+	return fmt.Sprintf("xjson: invalid JSONPath %q: %s (pos=%d)", e.expr, e.msg, e.pos)
+}
+
+func parse_query(expr string) (q *jp_query, err error) {
+	p := &query_parser{src: expr}
+
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*query_error)
+			if !ok {
+				panic(r)
+			}
+			q, err = nil, e
+		}
+	}()
+
+	if !p.scan_byte('$') {
+		p.fail("expected '$'")
+	}
+	q = &jp_query{absolute: true, segments: p.parse_segments()}
+	if p.pos < len(p.src) {
+		p.fail("unexpected %q", p.src[p.pos])
+	}
+
+	return q, nil
+}
+
+func (p *query_parser) fail(format string, args ...interface{}) {
+	panic(&query_error{p.src, fmt.Sprintf(format, args...), p.pos})
+}
+
+func (p *query_parser) peek() int {
+	if p.pos < len(p.src) {
+		return int(p.src[p.pos])
+	}
+	return -1
+}
+
+func (p *query_parser) peek_at(n int) int {
+	if p.pos+n < len(p.src) {
+		return int(p.src[p.pos+n])
+	}
+	return -1
+}
+
+func (p *query_parser) scan_byte(c byte) bool {
+	if p.peek() == int(c) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *query_parser) expect(c byte) {
+	if !p.scan_byte(c) {
+		if p.pos < len(p.src) {
+			p.fail("expected %q but found %q", c, p.src[p.pos])
+		}
+		p.fail("expected %q but found end of input", c)
+	}
+}
+
+func (p *query_parser) skip_whitespace() {
+	for {
+		switch p.peek() {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *query_parser) parse_segments() []jp_segment {
+	var segments []jp_segment
+
+	for {
+		save := p.pos
+		p.skip_whitespace()
+
+		switch {
+		case p.peek() == '.' && p.peek_at(1) == '.':
+			p.pos += 2
+			seg := jp_segment{descendant: true}
+			switch {
+			case p.peek() == '[':
+				seg.selectors = p.parse_bracketed()
+			case p.scan_byte('*'):
+				seg.selectors = []jp_selector{{kind: jp_wildcard}}
+			default:
+				seg.selectors = []jp_selector{{kind: jp_name, name: p.parse_member_name()}}
+			}
+			segments = append(segments, seg)
+
+		case p.scan_byte('.'):
+			var seg jp_segment
+			if p.scan_byte('*') {
+				seg.selectors = []jp_selector{{kind: jp_wildcard}}
+			} else {
+				seg.selectors = []jp_selector{{kind: jp_name, name: p.parse_member_name()}}
+			}
+			segments = append(segments, seg)
+
+		case p.peek() == '[':
+			segments = append(segments, jp_segment{selectors: p.parse_bracketed()})
+
+		default:
+			p.pos = save
+			return segments
+		}
+	}
+}
+
+func (p *query_parser) parse_member_name() string {
+	beg := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !is_name_char(r, p.pos == beg) || (r == utf8.RuneError && size == 1) {
+			break
+		}
+		p.pos += size
+	}
+	if p.pos == beg {
+		p.fail("expected member name")
+	}
+	return p.src[beg:p.pos]
+}
+
+func is_name_char(r rune, first bool) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', r == '_':
+		return true
+	case '0' <= r && r <= '9':
+		return !first
+	case r >= 0x80:
+		return !utf16.IsSurrogate(r)
+	default:
+		return false
+	}
+}
+
+func (p *query_parser) parse_bracketed() []jp_selector {
+	var selectors []jp_selector
+
+	p.expect('[')
+	for {
+		p.skip_whitespace()
+		selectors = append(selectors, p.parse_selector())
+		p.skip_whitespace()
+		if p.scan_byte(',') {
+			continue
+		}
+		p.expect(']')
+		return selectors
+	}
+}
+
+func (p *query_parser) parse_selector() jp_selector {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		return jp_selector{kind: jp_name, name: p.parse_string()}
+
+	case c == '*':
+		p.pos++
+		return jp_selector{kind: jp_wildcard}
+
+	case c == '?':
+		p.pos++
+		p.skip_whitespace()
+		e := p.parse_or()
+		p.check_logical(e)
+		return jp_selector{kind: jp_filter, filter: e}
+
+	case c == '-' || c == ':' || ('0' <= c && c <= '9'):
+		var s jp_selector
+
+		if c != ':' {
+			i := p.parse_int()
+			s.slice[0] = &i
+			p.skip_whitespace()
+			if !p.scan_byte(':') {
+				return jp_selector{kind: jp_index, index: i}
+			}
+		} else {
+			p.pos++
+		}
+
+		s.kind = jp_slice
+		p.skip_whitespace()
+		if c := p.peek(); c == '-' || ('0' <= c && c <= '9') {
+			i := p.parse_int()
+			s.slice[1] = &i
+			p.skip_whitespace()
+		}
+		if p.scan_byte(':') {
+			p.skip_whitespace()
+			if c := p.peek(); c == '-' || ('0' <= c && c <= '9') {
+				i := p.parse_int()
+				s.slice[2] = &i
+			}
+		}
+		return s
+
+	default:
+		if c < 0 {
+			p.fail("unexpected end of input")
+		}
+		p.fail("unexpected %q", rune(c))
+		return jp_selector{}
+	}
+}
+
+const jp_max_int = 1<<53 - 1
+
+func (p *query_parser) parse_int() int64 {
+	beg := p.pos
+	p.scan_byte('-')
+	if p.scan_byte('0') {
+		if p.pos-beg == 2 {
+			p.fail("-0 is not a valid integer")
+		}
+	} else {
+		digits := p.pos
+		for c := p.peek(); '0' <= c && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == digits {
+			p.fail("expected integer")
+		}
+	}
+
+	i, err := strconv.ParseInt(p.src[beg:p.pos], 10, 64)
+	if err != nil || i > jp_max_int || i < -jp_max_int {
+		p.pos = beg
+		p.fail("integer out of range")
+	}
+	return i
+}
+
+func (p *query_parser) parse_string() string {
+	var (
+		quote = p.src[p.pos]
+		buf   []byte
+	)
+
+	p.pos++
+	for {
+		if p.pos >= len(p.src) {
+			p.fail("unterminated string literal")
+		}
+
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return string(buf)
+
+		case c < 0x20:
+			p.fail("invalid control character in string literal")
+
+		case c == '\\':
+			p.pos++
+			switch e := p.peek(); e {
+			case 'b':
+				buf = append(buf, '\b')
+			case 'f':
+				buf = append(buf, '\f')
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case '/', '\\':
+				buf = append(buf, byte(e))
+			case '\'', '"':
+				if e != int(quote) {
+					p.fail("invalid escape sequence")
+				}
+				buf = append(buf, byte(e))
+			case 'u':
+				r := p.parse_u4()
+				if utf16.IsSurrogate(r) {
+					if r >= 0xDC00 || p.peek_at(1) != '\\' || p.peek_at(2) != 'u' {
+						p.fail("invalid surrogate pair")
+					}
+					p.pos += 2
+					r = utf16.DecodeRune(r, p.parse_u4())
+					if r == utf8.RuneError {
+						p.fail("invalid surrogate pair")
+					}
+				}
+				buf = append(buf, string(r)...)
+			default:
+				p.fail("invalid escape sequence")
+			}
+			p.pos++
+
+		default:
+			buf = append(buf, c)
+			p.pos++
+		}
+	}
+}
+
+// parse_u4 parses the four hex digits following `\u`; on return pos points
+// at the last digit.
+func (p *query_parser) parse_u4() rune {
+	if p.pos+5 > len(p.src) {
+		p.fail("invalid unicode escape")
+	}
+	r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 32)
+	if err != nil {
+		p.fail("invalid unicode escape")
+	}
+	p.pos += 4
+	return rune(r)
+}
+
+func (p *query_parser) parse_or() jp_expr {
+	var exprs jp_or
+
+	for {
+		exprs = append(exprs, p.parse_and())
+		p.skip_whitespace()
+		if p.peek() == '|' && p.peek_at(1) == '|' {
+			p.pos += 2
+			p.skip_whitespace()
+			continue
+		}
+		break
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	for _, e := range exprs {
+		p.check_logical(e)
+	}
+	return exprs
+}
+
+func (p *query_parser) parse_and() jp_expr {
+	var exprs jp_and
+
+	for {
+		exprs = append(exprs, p.parse_basic())
+		p.skip_whitespace()
+		if p.peek() == '&' && p.peek_at(1) == '&' {
+			p.pos += 2
+			p.skip_whitespace()
+			continue
+		}
+		break
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	for _, e := range exprs {
+		p.check_logical(e)
+	}
+	return exprs
+}
+
+func (p *query_parser) parse_basic() jp_expr {
+	if p.scan_byte('!') {
+		p.skip_whitespace()
+		if p.scan_byte('(') {
+			p.skip_whitespace()
+			e := p.parse_or()
+			p.check_logical(e)
+			p.skip_whitespace()
+			p.expect(')')
+			return jp_not{e}
+		}
+		e := p.parse_primary()
+		p.check_logical(e)
+		return jp_not{e}
+	}
+
+	if p.scan_byte('(') {
+		p.skip_whitespace()
+		e := p.parse_or()
+		p.check_logical(e)
+		p.skip_whitespace()
+		p.expect(')')
+		return e
+	}
+
+	l := p.parse_primary()
+
+	save := p.pos
+	p.skip_whitespace()
+	op := p.parse_compare_op()
+	if op == "" {
+		p.pos = save
+		return l
+	}
+
+	p.check_comparable(l)
+	p.skip_whitespace()
+	r := p.parse_primary()
+	p.check_comparable(r)
+
+	return &jp_compare{op, l, r}
+}
+
+func (p *query_parser) parse_compare_op() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *query_parser) parse_primary() jp_expr {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		return &jp_query{absolute: c == '$', segments: p.parse_segments()}
+
+	case c == '\'' || c == '"':
+		return &jp_literal{p.parse_string()}
+
+	case c == '-' || ('0' <= c && c <= '9'):
+		return &jp_literal{p.parse_number()}
+
+	case 'a' <= c && c <= 'z':
+		beg := p.pos
+		for c := p.peek(); ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.src[beg:p.pos]
+
+		if p.peek() == '(' {
+			return p.parse_call(name, beg)
+		}
+
+		switch name {
+		case "true":
+			return &jp_literal{true}
+		case "false":
+			return &jp_literal{false}
+		case "null":
+			return &jp_literal{nil}
+		}
+		p.pos = beg
+		p.fail("unexpected %q", name)
+
+	case c < 0:
+		p.fail("unexpected end of input")
+
+	default:
+		p.fail("unexpected %q", rune(c))
+	}
+	return nil
+}
+
+func (p *query_parser) parse_number() interface{} {
+	beg := p.pos
+	is_float := false
+
+	p.scan_byte('-')
+	if !p.scan_byte('0') {
+		digits := p.pos
+		for c := p.peek(); '0' <= c && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == digits {
+			p.fail("expected number")
+		}
+	}
+
+	if p.scan_byte('.') {
+		is_float = true
+		digits := p.pos
+		for c := p.peek(); '0' <= c && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == digits {
+			p.fail("expected fraction digits")
+		}
+	}
+
+	if c := p.peek(); c == 'e' || c == 'E' {
+		is_float = true
+		p.pos++
+		if c := p.peek(); c == '+' || c == '-' {
+			p.pos++
+		}
+		digits := p.pos
+		for c := p.peek(); '0' <= c && c <= '9'; c = p.peek() {
+			p.pos++
+		}
+		if p.pos == digits {
+			p.fail("expected exponent digits")
+		}
+	}
+
+	lit := p.src[beg:p.pos]
+	if !is_float {
+		if i, err := strconv.ParseInt(lit, 10, 64); err == nil {
+			return i
+		}
+	}
+
+	f, err := strconv.ParseFloat(lit, 64)
+	if err != nil || math.IsInf(f, 0) {
+		p.pos = beg
+		p.fail("number out of range")
+	}
+	return f
+}
+
+func (p *query_parser) parse_call(name string, beg int) jp_expr {
+	fn := jp_functions[name]
+	if fn == nil {
+		p.pos = beg
+		p.fail("unknown function %s()", name)
+	}
+
+	c := &jp_call{name: name, fn: fn}
+
+	p.expect('(')
+	p.skip_whitespace()
+	if p.peek() != ')' {
+		for {
+			c.args = append(c.args, p.parse_or())
+			p.skip_whitespace()
+			if p.scan_byte(',') {
+				p.skip_whitespace()
+				continue
+			}
+			break
+		}
+	}
+	p.expect(')')
+
+	if len(c.args) != len(fn.params) {
+		p.pos = beg
+		p.fail("%s() expects %d arguments", name, len(fn.params))
+	}
+
+	for i, arg := range c.args {
+		switch fn.params[i] {
+		case jp_value_type:
+			if !is_comparable(arg) {
+				p.pos = beg
+				p.fail("argument %d of %s() must be a value", i+1, name)
+			}
+		case jp_logical_type:
+			if !is_logical(arg) {
+				p.pos = beg
+				p.fail("argument %d of %s() must be a logical expression", i+1, name)
+			}
+		case jp_nodes_type:
+			ok := false
+			switch y := arg.(type) {
+			case *jp_query:
+				ok = true
+			case *jp_call:
+				ok = y.fn.result == jp_nodes_type
+			}
+			if !ok {
+				p.pos = beg
+				p.fail("argument %d of %s() must be a query", i+1, name)
+			}
+		}
+	}
+
+	// precompile constant regular expressions
+	if name == "match" || name == "search" {
+		if l, ok := c.args[1].(*jp_literal); ok {
+			if s, ok := l.value.(string); ok {
+				c.re, _ = compile_iregexp(s, name == "match")
+			}
+		}
+	}
+
+	return c
+}
+
+func (p *query_parser) check_logical(e jp_expr) {
+	if !is_logical(e) {
+		p.fail("expected a logical expression")
+	}
+}
+
+func (p *query_parser) check_comparable(e jp_expr) {
+	if !is_comparable(e) {
+		p.fail("expected a literal, a singular query or a value function")
+	}
+}
+
+func is_logical(e jp_expr) bool {
+	switch y := e.(type) {
+	case *jp_literal:
+		return false
+	case *jp_call:
+		return y.fn.result != jp_value_type
+	default:
+		return true
+	}
+}
+
+func is_comparable(e jp_expr) bool {
+	switch y := e.(type) {
+	case *jp_literal:
+		return true
+	case *jp_query:
+		return y.is_singular()
+	case *jp_call:
+		return y.fn.result == jp_value_type
+	default:
+		return false
+	}
+}
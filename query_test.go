@@ -0,0 +1,155 @@
+package xjson
+
+import (
+	"strings"
+	"testing"
+)
+
+var query_store = `
+{ "store": {
+    "book": [
+      { "category": "reference",
+        "author": "Nigel Rees",
+        "title": "Sayings of the Century",
+        "price": 8.95
+      },
+      { "category": "fiction",
+        "author": "Evelyn Waugh",
+        "title": "Sword of Honour",
+        "price": 12.99
+      },
+      { "category": "fiction",
+        "author": "Herman Melville",
+        "title": "Moby Dick",
+        "isbn": "0-553-21311-3",
+        "price": 8.99
+      },
+      { "category": "fiction",
+        "author": "J. R. R. Tolkien",
+        "title": "The Lord of the Rings",
+        "isbn": "0-395-19395-8",
+        "price": 22.99
+      }
+    ],
+    "bicycle": {
+      "color": "red",
+      "price": 399
+    }
+  }
+}`
+
+func TestQuery(t *testing.T) {
+	var tests = []struct {
+		doc  string
+		expr string
+		out  []string
+	}{
+		{query_store, `$.store.book[*].author`, []string{
+			`$root.store.book[0].author`,
+			`$root.store.book[1].author`,
+			`$root.store.book[2].author`,
+			`$root.store.book[3].author`,
+		}},
+		{query_store, `$..author`, []string{
+			`$root.store.book[0].author`,
+			`$root.store.book[1].author`,
+			`$root.store.book[2].author`,
+			`$root.store.book[3].author`,
+		}},
+		{query_store, `$.store.*`, []string{
+			`$root.store.bicycle`,
+			`$root.store.book`,
+		}},
+		{query_store, `$.store..price`, []string{
+			`$root.store.bicycle.price`,
+			`$root.store.book[0].price`,
+			`$root.store.book[1].price`,
+			`$root.store.book[2].price`,
+			`$root.store.book[3].price`,
+		}},
+		{query_store, `$..book[2]`, []string{`$root.store.book[2]`}},
+		{query_store, `$..book[-1]`, []string{`$root.store.book[3]`}},
+		{query_store, `$..book[0,1]`, []string{`$root.store.book[0]`, `$root.store.book[1]`}},
+		{query_store, `$..book[:2]`, []string{`$root.store.book[0]`, `$root.store.book[1]`}},
+		{query_store, `$..book[?@.isbn]`, []string{`$root.store.book[2]`, `$root.store.book[3]`}},
+		{query_store, `$..book[?@.price<10]`, []string{`$root.store.book[0]`, `$root.store.book[2]`}},
+		{query_store, `$..book[?(@.price > 10 && @.category == 'fiction')].title`, []string{
+			`$root.store.book[1].title`,
+			`$root.store.book[3].title`,
+		}},
+		{query_store, `$..book[?!@.isbn]`, []string{`$root.store.book[0]`, `$root.store.book[1]`}},
+		{query_store, `$..book[?@.price == $.store.bicycle.price]`, nil},
+		{query_store, `$.store["book"][0]['title']`, []string{`$root.store.book[0].title`}},
+		{query_store, `$..book[?match(@.author, 'J.*')]`, []string{`$root.store.book[3]`}},
+		{query_store, `$..book[?search(@.title, 'of')]`, []string{`$root.store.book[0]`, `$root.store.book[1]`, `$root.store.book[3]`}},
+		{query_store, `$..book[?length(@.title) > 15]`, []string{`$root.store.book[0]`, `$root.store.book[3]`}},
+		{query_store, `$.store[?count(@.*) > 2]`, []string{`$root.store.book`}},
+		{query_store, `$.store.book[?value(@..isbn) == "0-553-21311-3"].author`, []string{`$root.store.book[2].author`}},
+
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[1:5:2]`, []string{`$root[1]`, `$root[3]`}},
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[5:1:-2]`, []string{`$root[5]`, `$root[3]`}},
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[::-4]`, []string{`$root[9]`, `$root[5]`, `$root[1]`}},
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[-2:]`, []string{`$root[8]`, `$root[9]`}},
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[1:3:0]`, nil},
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[?@ >= 8]`, []string{`$root[8]`, `$root[9]`}},
+		{`[0,1,2,3,4,5,6,7,8,9]`, `$[?@ == 3.0]`, []string{`$root[3]`}},
+
+		{`{"a b": {"c": [1, {"d": null}]}}`, `$['a b'].c[1].d`, []string{`$root["a b"].c[1].d`}},
+		{`{"a b": {"c": [1, {"d": null}]}}`, `$..[?@ == null]`, []string{`$root["a b"].c[1].d`}},
+		{`{"a": [{"b": 1}, {"b": [1]}, {"b": {"x": 1}}], "c": [1.0]}`, `$.a[?@.b == $.c]`, []string{`$root.a[1]`}},
+		{`{"a": [{"b": 1}, {"b": [1]}, {"b": {"x": 1}}], "c": {"x": 1}}`, `$.a[?@.b == $.c]`, []string{`$root.a[2]`}},
+		{`{"a": [{"b": 1}, {"c": 2}]}`, `$.a[?@.b == @.x]`, []string{`$root.a[1]`}},
+		{`{"a": [{"b": "x"}, {"b": "y"}]}`, `$.a[?@.b < 'y']`, []string{`$root.a[0]`}},
+	}
+
+	for _, test := range tests {
+		nodes, err := Parse([]byte(test.doc)).Query(test.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.expr, err)
+			continue
+		}
+
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.Selector().String())
+		}
+
+		if strings.Join(out, "\n") != strings.Join(test.out, "\n") {
+			t.Errorf("%s:\n  expected %q\n  got      %q", test.expr, test.out, out)
+		}
+	}
+}
+
+func TestQuery_invalid(t *testing.T) {
+	var tests = []string{
+		``,
+		`store`,
+		` $`,
+		`$ `,
+		`$.`,
+		`$..`,
+		`$[`,
+		`$[01]`,
+		`$[-0]`,
+		`$[9007199254740992]`,
+		`$['a'`,
+		`$["\a"]`,
+		`$[?@.a == 1 == 2]`,
+		`$[?1]`,
+		`$[?@.* == 1]`,
+		`$[?length(@.*) == 1]`,
+		`$[?count(1) == 1]`,
+		`$[?length(@.a)]`,
+		`$[?foo(@.a)]`,
+		`$[?match(@.a)]`,
+		`$[?@.a && 1]`,
+		`$[?@.b == [1]]`,
+	}
+
+	for _, expr := range tests {
+		_, err := Parse([]byte(query_store)).Query(expr)
+		if err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
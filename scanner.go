@@ -0,0 +1,225 @@
+package xjson
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/fd/xjson/internal/jsontext"
+)
+
+// scanner reads json lexemes from an io.Reader one byte at a time, in the
+// same style as the []byte scanner in exp. Only the lexeme being scanned and
+// the tail of the current line (for error snippets) are buffered.
+type scanner struct {
+	r    io.ByteReader
+	pos  int
+	line int
+	col  int
+	chr  int
+	err  error
+	buf  []byte
+	text []byte
+}
+
+func new_scanner(r io.Reader) *scanner {
+	return new_scanner_at(r, Position{0, 1, 1})
+}
+
+// new_scanner_at returns a scanner whose first byte is at start.
+func new_scanner_at(r io.Reader, start Position) *scanner {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	s := &scanner{r: br, pos: start.Offset - 1, line: start.Line, col: start.Column - 1, chr: -2}
+	s.next()
+	return s
+}
+
+// position returns the position of the current byte.
+func (s *scanner) position() Position {
+	return Position{s.pos, s.line, s.col}
+}
+
+func (s *scanner) syntax_error(format string, a ...interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	return fmt.Errorf("xjson: %s (%s)", fmt.Sprintf(format, a...), s.position())
+}
+
+// unexpected reports the current byte as a *SyntaxError. The rest of the
+// line is read for the error's snippet, so the scanner can't be used
+// afterwards.
+func (s *scanner) unexpected(expected string) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	e := &SyntaxError{Position: s.position(), Byte: s.chr, Expected: expected}
+
+	var (
+		line = append([]byte(nil), s.text...)
+		col  = len(line)
+	)
+	if s.chr >= 0 && s.chr != '\n' {
+		line = append(line, byte(s.chr))
+		for len(line) < col+snippet_width {
+			c, err := s.r.ReadByte()
+			if err != nil || c == '\n' {
+				break
+			}
+			line = append(line, c)
+		}
+	}
+	e.line, e.col = snippet_window(line, col)
+
+	return e
+}
+
+func (s *scanner) next() {
+	if s.chr == -1 {
+		return
+	}
+	if s.chr == '\n' {
+		s.line++
+		s.col = 0
+		s.text = s.text[:0]
+	} else if s.chr >= 0 {
+		s.text = append(s.text, byte(s.chr))
+		if len(s.text) > 4*snippet_width {
+			s.text = append(s.text[:0], s.text[len(s.text)-snippet_width:]...)
+		}
+	}
+	s.pos++
+	s.col++
+	c, err := s.r.ReadByte()
+	if err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		s.chr = -1
+		return
+	}
+	s.chr = int(c)
+}
+
+// take appends the current byte to the lexeme buffer and advances.
+func (s *scanner) take() {
+	s.buf = append(s.buf, byte(s.chr))
+	s.next()
+}
+
+func (s *scanner) skip_whitespace() {
+	for jsontext.IsSpace(s.chr) {
+		s.next()
+	}
+}
+
+func (s *scanner) scan_byte(c byte) bool {
+	if s.chr == int(c) {
+		s.next()
+		return true
+	}
+	return false
+}
+
+func (s *scanner) scan_literal(lit string) error {
+	for i := 0; i < len(lit); i++ {
+		if !s.scan_byte(lit[i]) {
+			return s.unexpected(strconv.Quote(lit))
+		}
+	}
+	return nil
+}
+
+func (s *scanner) scan_string() (string, error) {
+	s.buf = s.buf[:0]
+
+	if s.chr != '"' {
+		return "", s.unexpected("string")
+	}
+	s.take()
+
+	for {
+		switch {
+		case s.chr == '"':
+			s.take()
+			var str string
+			if err := json.Unmarshal(s.buf, &str); err != nil {
+				return "", s.syntax_error("invalid string %q", s.buf)
+			}
+			return str, nil
+
+		case s.chr == '\\':
+			s.take()
+			switch s.chr {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				s.take()
+			case 'u':
+				s.take()
+				for i := 0; i < 4; i++ {
+					if !jsontext.IsHexDigit(s.chr) {
+						return "", s.unexpected("hex digit")
+					}
+					s.take()
+				}
+			default:
+				return "", s.unexpected("escape sequence")
+			}
+
+		case s.chr < 0x20:
+			return "", s.unexpected(`'"'`)
+
+		default:
+			s.take()
+		}
+	}
+}
+
+func (s *scanner) scan_number() (interface{}, error) {
+	s.buf = s.buf[:0]
+
+	if s.chr == '-' {
+		s.take()
+	}
+
+	if s.chr == '0' {
+		s.take()
+	} else if '1' <= s.chr && s.chr <= '9' {
+		s.scan_dec_digits()
+	} else {
+		return nil, s.unexpected("digit")
+	}
+
+	if s.chr == '.' {
+		s.take()
+		if !s.scan_dec_digits() {
+			return nil, s.unexpected("digit")
+		}
+	}
+
+	if s.chr == 'e' || s.chr == 'E' {
+		s.take()
+		if s.chr == '+' || s.chr == '-' {
+			s.take()
+		}
+		if !s.scan_dec_digits() {
+			return nil, s.unexpected("digit")
+		}
+	}
+
+	return parse_number(string(s.buf)), nil
+}
+
+func (s *scanner) scan_dec_digits() bool {
+	ok := false
+	for '0' <= s.chr && s.chr <= '9' {
+		ok = true
+		s.take()
+	}
+	return ok
+}
@@ -2,16 +2,22 @@ package xjson
 
 import (
 	"fmt"
+	"strconv"
 	"unicode"
+	"unicode/utf8"
 )
 
 type Selector interface {
 	Value() Value
 	String() string
+
+	// Pointer renders the selector as a RFC 6901 JSON Pointer.
+	Pointer() string
 }
 
 type root_selector struct {
 	value interface{}
+	spans *span
 }
 
 func (i *root_selector) Value() Value {
@@ -22,6 +28,29 @@ func (i *root_selector) String() string {
 	return "$root"
 }
 
+func (i *root_selector) Pointer() string {
+	return ""
+}
+
+// line_selector is the root selector of a value read by a LineReader.
+type line_selector struct {
+	value interface{}
+	line  int
+	spans *span
+}
+
+func (i *line_selector) Value() Value {
+	return Value{i.value, nil, i}
+}
+
+func (i *line_selector) String() string {
+	return fmt.Sprintf("$line[%d]", i.line)
+}
+
+func (i *line_selector) Pointer() string {
+	return ""
+}
+
 type index_selector struct {
 	value  interface{}
 	idx    int
@@ -36,6 +65,10 @@ func (i *index_selector) String() string {
 	return fmt.Sprintf("%s[%d]", i.parent, i.idx)
 }
 
+func (i *index_selector) Pointer() string {
+	return i.parent.Pointer() + "/" + strconv.Itoa(i.idx)
+}
+
 type key_selector struct {
 	value  interface{}
 	key    string
@@ -54,7 +87,32 @@ func (i *key_selector) String() string {
 	}
 }
 
+func (i *key_selector) Pointer() string {
+	return i.parent.Pointer() + "/" + escape_pointer_token(i.key)
+}
+
+// wildcard_selector matches every member of its parent. It only appears in
+// selector patterns, never in the selector of a Value.
+type wildcard_selector struct {
+	parent Selector
+}
+
+func (i *wildcard_selector) Value() Value {
+	return Value{}
+}
+
+func (i *wildcard_selector) String() string {
+	return fmt.Sprintf("%s[*]", i.parent)
+}
+
+func (i *wildcard_selector) Pointer() string {
+	return i.parent.Pointer() + "/*"
+}
+
 func is_keyword(s string) bool {
+	if s == "" {
+		return false
+	}
 	for i, r := range s {
 		if i == 0 {
 			if !unicode.IsLetter(r) && r != '_' {
@@ -68,3 +126,135 @@ func is_keyword(s string) bool {
 	}
 	return true
 }
+
+// ParseSelector parses the output of Selector.String() back into a
+// Selector. The returned selector does not carry a value; use Value.At() to
+// apply it to a document.
+func ParseSelector(s string) (Selector, error) {
+	return parse_selector(s, false)
+}
+
+// parse_selector parses a selector. When wildcards is set `[*]` is accepted
+// and yields a *wildcard_selector.
+func parse_selector(s string, wildcards bool) (Selector, error) {
+	const root = "$root"
+
+	if len(s) < len(root) || s[:len(root)] != root {
+		return nil, fmt.Errorf("xjson: invalid selector %q: expected %s (pos=0)", s, root)
+	}
+
+	var (
+		sel Selector = &root_selector{}
+		pos          = len(root)
+	)
+
+	fail := func(msg string) (Selector, error) {
+		return nil, fmt.Errorf("xjson: invalid selector %q: %s (pos=%d)", s, msg, pos)
+	}
+
+	for pos < len(s) {
+		switch s[pos] {
+		case '.':
+			pos++
+			beg := pos
+			for pos < len(s) {
+				r, size := utf8.DecodeRuneInString(s[pos:])
+				if !unicode.IsLetter(r) && r != '_' && (pos == beg || !unicode.IsDigit(r)) {
+					break
+				}
+				pos += size
+			}
+			if pos == beg {
+				return fail("expected key")
+			}
+			sel = &key_selector{nil, s[beg:pos], sel}
+
+		case '[':
+			pos++
+			if wildcards && pos < len(s) && s[pos] == '*' {
+				pos++
+				sel = &wildcard_selector{sel}
+			} else if pos < len(s) && s[pos] == '"' {
+				beg := pos
+				for pos++; pos < len(s) && s[pos] != '"'; pos++ {
+					if s[pos] == '\\' {
+						pos++
+					}
+				}
+				if pos >= len(s) {
+					return fail("unterminated key")
+				}
+				pos++
+				key, err := strconv.Unquote(s[beg:pos])
+				if err != nil {
+					pos = beg
+					return fail("invalid key")
+				}
+				sel = &key_selector{nil, key, sel}
+			} else {
+				beg := pos
+				for pos < len(s) && '0' <= s[pos] && s[pos] <= '9' {
+					pos++
+				}
+				idx, err := strconv.Atoi(s[beg:pos])
+				if err != nil {
+					pos = beg
+					return fail("expected index or quoted key")
+				}
+				sel = &index_selector{nil, idx, sel}
+			}
+			if pos >= len(s) || s[pos] != ']' {
+				return fail("expected ']'")
+			}
+			pos++
+
+		default:
+			return fail(fmt.Sprintf("unexpected %q", s[pos]))
+		}
+	}
+
+	return sel, nil
+}
+
+// selector_path returns the keys and indexes leading from the root to sel,
+// in the form expected by GetPath().
+func selector_path(sel Selector) ([]interface{}, error) {
+	var parts []interface{}
+
+	for {
+		switch s := sel.(type) {
+		case nil, *root_selector, *line_selector:
+			for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+				parts[i], parts[j] = parts[j], parts[i]
+			}
+			return parts, nil
+		case *index_selector:
+			parts = append(parts, s.idx)
+			sel = s.parent
+		case *key_selector:
+			parts = append(parts, s.key)
+			sel = s.parent
+		default:
+			return nil, fmt.Errorf("xjson: unsupported selector type %T", sel)
+		}
+	}
+}
+
+// path_selector builds a selector from GetPath() style parts.
+func path_selector(parts []interface{}) Selector {
+	return path_selector_from(&root_selector{}, parts)
+}
+
+// path_selector_from builds a selector from GetPath() style parts, starting
+// at sel.
+func path_selector_from(sel Selector, parts []interface{}) Selector {
+	for _, part := range parts {
+		switch p := part.(type) {
+		case int:
+			sel = &index_selector{nil, p, sel}
+		case string:
+			sel = &key_selector{nil, p, sel}
+		}
+	}
+	return sel
+}
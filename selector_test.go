@@ -0,0 +1,62 @@
+package xjson
+
+import (
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	var js = `
+		{
+			"people": [
+				{ "name": "Simon Menke" },
+				{ "name": "Hans Spooren", "first name": "Hans", "": 1, "\"quoted\"\n": 2, "naïve": 3, "1st": 4 }
+				]
+			}
+		`
+
+	var (
+		r    = Parse([]byte(js))
+		keys = []string{"name", "first name", "", "\"quoted\"\n", "naïve", "1st"}
+	)
+
+	for _, key := range keys {
+		x := r.Get("people").GetIndex(1).Get(key)
+
+		sel, err := ParseSelector(x.Selector().String())
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", x.Selector(), err)
+			continue
+		}
+		if sel.String() != x.Selector().String() {
+			t.Errorf("%s: round trip produced %s", x.Selector(), sel)
+		}
+
+		y := r.At(sel)
+		if y.err != nil || !equal_inner(x.inner, y.inner) {
+			t.Errorf("%s: At() returned %v (err=%v)", sel, y.inner, y.err)
+		}
+	}
+}
+
+func TestParseSelector_invalid(t *testing.T) {
+	var tests = []string{
+		``,
+		`$rot`,
+		`$root.`,
+		`$root.1st`,
+		`$root[`,
+		`$root[1`,
+		`$root[-1]`,
+		`$root["a]`,
+		`$root["a"`,
+		`$root["\q"]`,
+		`$root..a`,
+		`$root a`,
+	}
+
+	for _, s := range tests {
+		if _, err := ParseSelector(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package xjson
+
+import (
+	"io"
+)
+
+// Stream reads the json documents in r and calls fn for every value whose
+// location matches one of paths. Paths use the selector syntax of
+// ParseSelector and may contain `[*]` to match any array element or object
+// member, e.g. `$root.events[*]`.
+//
+// Only the matching values are materialized, everything else is skipped
+// token by token. Values nested inside a match are not reported separately.
+// Stream stops at the first error returned by fn.
+func Stream(r io.Reader, paths []string, fn func(Value) error) error {
+	patterns := make([][]interface{}, len(paths))
+	for i, path := range paths {
+		sel, err := parse_selector(path, true)
+		if err != nil {
+			return err
+		}
+		patterns[i] = pattern_path(sel)
+	}
+
+	d := NewDecoder(r)
+	for {
+		tok, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if tok.Kind == KeyToken || tok.Delim == '}' || tok.Delim == ']' {
+			continue
+		}
+		if !match_patterns(patterns, tok.Selector) {
+			continue
+		}
+
+		inner, _, err := d.read_value(tok)
+		if err != nil {
+			return err
+		}
+
+		sel := with_selector_value(tok.Selector, inner)
+		if err := fn(Value{inner, nil, sel}); err != nil {
+			return err
+		}
+	}
+}
+
+// wildcard marks a `[*]` in a pattern path.
+type wildcard struct{}
+
+func pattern_path(sel Selector) []interface{} {
+	var parts []interface{}
+
+	for {
+		switch s := sel.(type) {
+		case *index_selector:
+			parts = append(parts, s.idx)
+			sel = s.parent
+		case *key_selector:
+			parts = append(parts, s.key)
+			sel = s.parent
+		case *wildcard_selector:
+			parts = append(parts, wildcard{})
+			sel = s.parent
+		default:
+			for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+				parts[i], parts[j] = parts[j], parts[i]
+			}
+			return parts
+		}
+	}
+}
+
+func match_patterns(patterns [][]interface{}, sel Selector) bool {
+	parts, _ := selector_path(sel)
+
+	for _, pattern := range patterns {
+		if match_pattern(pattern, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+func match_pattern(pattern, parts []interface{}) bool {
+	if len(pattern) != len(parts) {
+		return false
+	}
+	for i, p := range pattern {
+		if _, ok := p.(wildcard); !ok && p != parts[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package xjson
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	var tests = []struct {
+		in    string
+		paths []string
+		out   []string
+		err   string
+	}{
+		{`{"a":[1,{"b":2},[3]],"c":{"b":4}}`, []string{`$root.a[*]`}, []string{
+			`$root.a[0] 1`,
+			`$root.a[1] {"b":2}`,
+			`$root.a[2] [3]`,
+		}, ``},
+		{`{"a":[1,{"b":2},[3]],"c":{"b":4}}`, []string{`$root[*].b`}, []string{
+			`$root.c.b 4`,
+		}, ``},
+		{`{"a":[1,{"b":2},[3]],"c":{"b":4}}`, []string{`$root.a[*].b`, `$root.c`}, []string{
+			`$root.a[1].b 2`,
+			`$root.c {"b":4}`,
+		}, ``},
+		{`{"a":[1,{"b":2},[3]],"c":{"b":4}}`, []string{`$root`}, []string{
+			`$root {"a":[1,{"b":2},[3]],"c":{"b":4}}`,
+		}, ``},
+		{`{"a":1} {"a":2}`, []string{`$root.a`}, []string{
+			`$root.a 1`,
+			`$root.a 2`,
+		}, ``},
+		{`{"a":[1,2,}`, []string{`$root.a[*]`}, []string{
+			`$root.a[0] 1`,
+			`$root.a[1] 2`,
+		}, `xjson: unexpected byte '}', expected value (at: $root.a[2], line 1 col 11)`},
+		{`{"a":[1,2]}`, []string{`$root.a[`}, nil, `xjson: invalid selector "$root.a[": expected index or quoted key (pos=8)`},
+	}
+
+	for _, test := range tests {
+		var out []string
+
+		err := Stream(strings.NewReader(test.in), test.paths, func(x Value) error {
+			out = append(out, x.Selector().String()+" "+describe_value(x))
+			return nil
+		})
+
+		if strings.Join(out, "\n") != strings.Join(test.out, "\n") {
+			t.Errorf("%s %q:\n  expected %q\n  got      %q", test.in, test.paths, test.out, out)
+		}
+		if test.err == "" && err != nil {
+			t.Errorf("%s %q: unexpected error: %s", test.in, test.paths, err)
+		}
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%s %q: expected error %q, got %v", test.in, test.paths, test.err, err)
+		}
+	}
+}
+
+func TestStream_stop(t *testing.T) {
+	var (
+		stop = errors.New("stop")
+		n    int
+	)
+
+	err := Stream(strings.NewReader(`[1,2,3]`), []string{`$root[*]`}, func(x Value) error {
+		n++
+		return stop
+	})
+
+	if err != stop || n != 1 {
+		t.Errorf("expected Stream to stop after the first value, got %d values and %v", n, err)
+	}
+}
@@ -0,0 +1,106 @@
+package xjson
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// SyntaxError describes malformed json. Position is the location of the
+// offending byte, which is -1 at the end of the input. Selector is the value
+// that was being read, or the enclosing container between values.
+type SyntaxError struct {
+	Position
+	Byte     int
+	Expected string
+	Selector Selector
+
+	line []byte // the source line containing the error, possibly truncated
+	col  int    // index of the offending byte in line
+}
+
+// new_syntax_error builds a SyntaxError for the byte at offset in src. The
+// exp parser reaches it through jsontext.NewSyntaxError.
+func new_syntax_error(src []byte, offset int, expected string, sel Selector) *SyntaxError {
+	e := &SyntaxError{
+		Position: position_at(src, offset),
+		Byte:     -1,
+		Expected: expected,
+		Selector: sel,
+	}
+	if offset < len(src) {
+		e.Byte = int(src[offset])
+	}
+
+	beg := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := bytes.IndexByte(src[offset:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += offset
+	}
+	e.line, e.col = snippet_window(src[beg:end], offset-beg)
+
+	return e
+}
+
+func (e *SyntaxError) Error() string {
+	msg := "unexpected end of input"
+	if e.Byte >= 0 {
+		msg = fmt.Sprintf("unexpected byte %q", rune(e.Byte))
+	}
+	if e.Expected != "" {
+		msg += ", expected " + e.Expected
+	}
+
+	if e.Selector == nil {
+		return fmt.Sprintf("xjson: %s (%s)", msg, e.Position)
+	}
+	return fmt.Sprintf("xjson: %s (at: %s, %s)", msg, e.Selector, e.Position)
+}
+
+// Snippet renders the source line containing the error with a caret under
+// the offending byte. Long lines are cut down to the part around the error.
+func (e *SyntaxError) Snippet() string {
+	var caret []byte
+
+	for b := e.line[:e.col]; len(b) > 0; {
+		r, size := utf8.DecodeRune(b)
+		if r == '\t' {
+			caret = append(caret, '\t')
+		} else {
+			caret = append(caret, ' ')
+		}
+		b = b[size:]
+	}
+
+	return fmt.Sprintf("%s\n%s^", bytes.TrimRight(e.line, "\r"), caret)
+}
+
+const snippet_width = 80
+
+// snippet_window cuts line down to at most snippet_width bytes around col.
+func snippet_window(line []byte, col int) ([]byte, int) {
+	if len(line) <= snippet_width {
+		return line, col
+	}
+
+	beg := col - snippet_width/2
+	if beg < 0 {
+		beg = 0
+	}
+	end := beg + snippet_width
+	if end > len(line) {
+		end, beg = len(line), len(line)-snippet_width
+	}
+
+	// don't cut utf-8 sequences in half
+	for beg > 0 && !utf8.RuneStart(line[beg]) {
+		beg--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+
+	return line[beg:end], col - beg
+}
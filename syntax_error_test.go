@@ -0,0 +1,56 @@
+package xjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	var tests = []struct {
+		in       string
+		byte     int
+		pos      Position
+		expected string
+		selector string
+		snippet  string
+	}{
+		{"{\n\t\"a\": [1, 2 }\n}", '}', Position{14, 2, 13}, "',' or ']' after array element", "$root.a",
+			"\t\"a\": [1, 2 }\n\t           ^"},
+		{`{"é": tru}`, '}', Position{10, 1, 11}, `"true"`, `$root.é`,
+			"{\"é\": tru}\n         ^"},
+		{`[1, 2`, -1, Position{5, 1, 6}, "',' or ']' after array element", "$root",
+			"[1, 2\n     ^"},
+		{`{"a":1} x`, 'x', Position{8, 1, 9}, "end of input", "",
+			"{\"a\":1} x\n        ^"},
+		{`[` + strings.Repeat("1,", 100) + `x]`, 'x', Position{201, 1, 202}, "value", "$root[100]",
+			strings.Repeat("1,", 39) + "x]\n" + strings.Repeat(" ", 78) + "^"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse([]byte(test.in)).Interface()
+
+		e, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%q: expected a *SyntaxError, got %v", test.in, err)
+			continue
+		}
+
+		var sel string
+		if e.Selector != nil {
+			sel = e.Selector.String()
+		}
+
+		if e.Byte != test.byte || e.Position != test.pos || e.Expected != test.expected || sel != test.selector {
+			t.Errorf("%q: unexpected error %+v", test.in, e)
+		}
+		if e.Snippet() != test.snippet {
+			t.Errorf("%q: expected snippet\n%s\ngot\n%s", test.in, test.snippet, e.Snippet())
+		}
+
+		// a []byte source gives the same result
+		o := new_syntax_error([]byte(test.in), e.Offset, e.Expected, e.Selector)
+		if o.Error() != e.Error() || o.Snippet() != e.Snippet() {
+			t.Errorf("%q: new_syntax_error differs:\n  %s\n  %s", test.in, o, e)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package xjson
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time parses x as a time in one of layouts, which defaults to RFC 3339.
+func (x Value) Time(layouts ...string) (time.Time, error) {
+	s, err := x.String()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, &selector_error{fmt.Errorf("xjson: %q does not match %s", s, strings.Join(layouts, " or ")), x.selector}
+}
+
+// Duration parses x as an ISO 8601 duration, see ParseDuration.
+func (x Value) Duration() (time.Duration, error) {
+	s, err := x.String()
+	if err != nil {
+		return 0, err
+	}
+
+	d, err := ParseDuration(s)
+	if err != nil {
+		return 0, &selector_error{err, x.selector}
+	}
+	return d, nil
+}
+
+// UUID parses x as a UUID, see ParseUUID.
+func (x Value) UUID() (UUID, error) {
+	s, err := x.String()
+	if err != nil {
+		return UUID{}, err
+	}
+
+	u, err := ParseUUID(s)
+	if err != nil {
+		return UUID{}, &selector_error{err, x.selector}
+	}
+	return u, nil
+}
+
+// Bytes decodes x as base64, using either the standard or the URL safe
+// alphabet, with or without padding.
+func (x Value) Bytes() ([]byte, error) {
+	s, err := x.String()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := DecodeBase64(s)
+	if err != nil {
+		return nil, &selector_error{err, x.selector}
+	}
+	return b, nil
+}
+
+func (x Value) MustTime(layouts ...string) time.Time {
+	v, _ := x.Time(layouts...)
+	return v
+}
+
+func (x Value) MustDuration() time.Duration {
+	v, _ := x.Duration()
+	return v
+}
+
+func (x Value) MustUUID() UUID {
+	v, _ := x.UUID()
+	return v
+}
+
+func (x Value) MustBytes() []byte {
+	v, _ := x.Bytes()
+	return v
+}
+
+// ParseDuration parses an ISO 8601 duration like P1DT2H30M or PT0.5S. Days
+// are 24 hours and weeks 7 days; years and months have no fixed length and
+// are rejected. A leading '-' negates the duration.
+func ParseDuration(s string) (time.Duration, error) {
+	var (
+		d       float64
+		in_time bool
+		rest    = s
+		invalid = fmt.Errorf("xjson: %q is not an ISO 8601 duration", s)
+	)
+
+	sign := 1.0
+	if strings.HasPrefix(rest, "-") {
+		sign, rest = -1, rest[1:]
+	}
+	if !strings.HasPrefix(rest, "P") || len(rest) == 1 {
+		return 0, invalid
+	}
+	rest = rest[1:]
+
+	for rest != "" {
+		if rest[0] == 'T' && !in_time && len(rest) > 1 {
+			in_time, rest = true, rest[1:]
+			continue
+		}
+
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+		if i <= 0 {
+			return 0, invalid
+		}
+		n, err := strconv.ParseFloat(strings.Replace(rest[:i], ",", ".", 1), 64)
+		if err != nil {
+			return 0, invalid
+		}
+
+		var unit time.Duration
+		switch {
+		case !in_time && rest[i] == 'W':
+			unit = 7 * 24 * time.Hour
+		case !in_time && rest[i] == 'D':
+			unit = 24 * time.Hour
+		case in_time && rest[i] == 'H':
+			unit = time.Hour
+		case in_time && rest[i] == 'M':
+			unit = time.Minute
+		case in_time && rest[i] == 'S':
+			unit = time.Second
+		case !in_time && (rest[i] == 'Y' || rest[i] == 'M'):
+			return 0, fmt.Errorf("xjson: %q has years or months, which have no fixed duration", s)
+		default:
+			return 0, invalid
+		}
+
+		d += n * float64(unit)
+		rest = rest[i+1:]
+	}
+
+	d = math.Round(sign * d)
+	if d > math.MaxInt64 || d < math.MinInt64 {
+		return 0, fmt.Errorf("xjson: %q overflows time.Duration", s)
+	}
+	return time.Duration(d), nil
+}
+
+// UUID is a 128 bit universally unique identifier.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in its canonical form,
+// e.g. 123e4567-e89b-12d3-a456-426614174000. Hex digits may be upper case.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("xjson: %q is not a UUID", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return UUID{}, fmt.Errorf("xjson: %q is not a UUID", s)
+	}
+	return u, nil
+}
+
+// String formats u in its canonical lower case form.
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// DecodeBase64 decodes s using the standard or the URL safe alphabet, with
+// or without padding.
+func DecodeBase64(s string) ([]byte, error) {
+	enc := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.URLEncoding
+	}
+	if !strings.HasSuffix(s, "=") {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("xjson: string is not base64 encoded: %s", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,93 @@
+package xjson
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestValue_Time(t *testing.T) {
+	v := Parse([]byte(`{"a": "2020-05-01T12:30:00Z", "b": "2020-05-01", "c": "yesterday", "d": 1}`))
+
+	if tm, err := v.Get("a").Time(); err != nil || !tm.Equal(time.Date(2020, 5, 1, 12, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time %s (%v)", tm, err)
+	}
+	if tm, err := v.Get("b").Time(time.RFC3339, "2006-01-02"); err != nil || !tm.Equal(time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time %s (%v)", tm, err)
+	}
+
+	_, err := v.Get("c").Time()
+	if err == nil || err.Error() != `xjson: "yesterday" does not match 2006-01-02T15:04:05.999999999Z07:00 (at: $root.c, line 1 col 55)` {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err = v.Get("d").Time()
+	if err == nil || err.Error() != `xjson: int64 is not a json string (at: $root.d, line 1 col 73)` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out time.Duration
+		err bool
+	}{
+		{"PT1H30M", 90 * time.Minute, false},
+		{"P1DT2H", 26 * time.Hour, false},
+		{"P2W", 14 * 24 * time.Hour, false},
+		{"PT0.5S", 500 * time.Millisecond, false},
+		{"PT1,5S", 1500 * time.Millisecond, false},
+		{"-PT10S", -10 * time.Second, false},
+		{"P1M", 0, true},
+		{"P1Y", 0, true},
+		{"PT1D", 0, true},
+		{"P", 0, true},
+		{"PT", 0, true},
+		{"1h", 0, true},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDuration(test.in)
+		if (err != nil) != test.err || d != test.out {
+			t.Errorf("%s: expected %s (error: %v), got %s (%v)", test.in, test.out, test.err, d, err)
+		}
+	}
+}
+
+func TestValue_UUID(t *testing.T) {
+	v := Parse([]byte(`["123E4567-e89b-12d3-a456-426614174000", "123e4567e89b12d3a456426614174000"]`))
+
+	u, err := v.GetIndex(0).UUID()
+	if err != nil || u.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("unexpected uuid %s (%v)", u, err)
+	}
+
+	_, err = v.GetIndex(1).UUID()
+	if err == nil || err.Error() != `xjson: "123e4567e89b12d3a456426614174000" is not a UUID (at: $root[1], line 1 col 42)` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestValue_Bytes(t *testing.T) {
+	var tests = []struct {
+		in  string
+		out string
+	}{
+		{`"aGk/Pz4+"`, "hi??>>"},
+		{`"aGk_Pz4-"`, "hi??>>"},
+		{`"aGk="`, "hi"},
+		{`"aGk"`, "hi"},
+		{`""`, ""},
+	}
+
+	for _, test := range tests {
+		b, err := Parse([]byte(test.in)).Bytes()
+		if err != nil || !bytes.Equal(b, []byte(test.out)) {
+			t.Errorf("%s: expected %q, got %q (%v)", test.in, test.out, b, err)
+		}
+	}
+
+	if _, err := Parse([]byte(`"a*b"`)).Bytes(); err == nil {
+		t.Errorf("expected an error for invalid base64")
+	}
+}
@@ -0,0 +1,253 @@
+package xjson
+
+import (
+	"fmt"
+)
+
+// Set returns a new root Value in which the value at path is replaced by v
+// (a Value or any type accepted by ValueOf). Missing object keys are added;
+// array indexes must exist. Like all selectors the path is absolute, e.g.
+// x.Get("a").Selector() or the result of ParseSelector("$root.cfg.a"), and
+// it must lie inside x. Only the containers along the path are copied, x
+// itself is never modified.
+func (x Value) Set(path Selector, v interface{}) Value {
+	y := ValueOf(v)
+	if y.err != nil {
+		return x.update_error(y.err)
+	}
+
+	parts, err := x.relative_path(path)
+	if err != nil {
+		return x.update_error(err)
+	}
+
+	if len(parts) == 0 {
+		return ValueOf(y)
+	}
+
+	last := parts[len(parts)-1]
+	return x.update(parts[:len(parts)-1], func(parent Value) (interface{}, error) {
+		switch key := last.(type) {
+		case string:
+			o, err := parent.Object()
+			if err != nil {
+				return nil, err
+			}
+			c := copy_object(o, len(o)+1)
+			c[key] = y.inner
+			return c, nil
+		default:
+			idx := key.(int)
+			if child := parent.GetIndex(idx); child.err != nil {
+				return nil, child.err
+			}
+			c := copy_array(parent.MustArray(), 0)
+			c[idx] = y.inner
+			return c, nil
+		}
+	})
+}
+
+// Delete returns a new root Value without the object member or array
+// element at path. Later array elements shift down by one.
+func (x Value) Delete(path Selector) Value {
+	parts, err := x.relative_path(path)
+	if err != nil {
+		return x.update_error(err)
+	}
+
+	if len(parts) == 0 {
+		return x.update_error(fmt.Errorf("xjson: cannot delete the root value"))
+	}
+
+	last := parts[len(parts)-1]
+	return x.update(parts[:len(parts)-1], func(parent Value) (interface{}, error) {
+		switch key := last.(type) {
+		case string:
+			if child := parent.Get(key); child.err != nil {
+				return nil, child.err
+			}
+			c := copy_object(parent.MustObject(), 0)
+			delete(c, key)
+			return c, nil
+		default:
+			idx := key.(int)
+			if child := parent.GetIndex(idx); child.err != nil {
+				return nil, child.err
+			}
+			a := parent.MustArray()
+			c := make([]interface{}, 0, len(a)-1)
+			c = append(c, a[:idx]...)
+			c = append(c, a[idx+1:]...)
+			return c, nil
+		}
+	})
+}
+
+// Insert returns a new root Value in which v is inserted into the array at
+// path before index idx. An idx equal to the array length appends v.
+func (x Value) Insert(path Selector, idx int, v interface{}) Value {
+	y := ValueOf(v)
+	if y.err != nil {
+		return x.update_error(y.err)
+	}
+
+	parts, err := x.relative_path(path)
+	if err != nil {
+		return x.update_error(err)
+	}
+
+	return x.update(parts, func(target Value) (interface{}, error) {
+		a, err := target.Array()
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx > len(a) {
+			sel := &index_selector{nil, idx, target.selector}
+			return nil, &selector_error{fmt.Errorf("xjson: index out of range"), sel}
+		}
+		c := make([]interface{}, 0, len(a)+1)
+		c = append(c, a[:idx]...)
+		c = append(c, y.inner)
+		c = append(c, a[idx:]...)
+		return c, nil
+	})
+}
+
+// Append returns a new root Value in which v is added to the end of the
+// array at path.
+func (x Value) Append(path Selector, v interface{}) Value {
+	y := ValueOf(v)
+	if y.err != nil {
+		return x.update_error(y.err)
+	}
+
+	parts, err := x.relative_path(path)
+	if err != nil {
+		return x.update_error(err)
+	}
+
+	return x.update(parts, func(target Value) (interface{}, error) {
+		a, err := target.Array()
+		if err != nil {
+			return nil, err
+		}
+		c := copy_array(a, 1)
+		c = append(c, y.inner)
+		return c, nil
+	})
+}
+
+// update rebuilds the containers along parts around the value returned by
+// fn, sharing everything else with x.
+func (x Value) update(parts []interface{}, fn func(Value) (interface{}, error)) Value {
+	if x.err != nil {
+		return x
+	}
+
+	inner, err := update_in(x, parts, fn)
+	if err != nil {
+		return x.update_error(err)
+	}
+
+	return Value{inner, nil, &root_selector{inner, nil}}
+}
+
+func update_in(x Value, parts []interface{}, fn func(Value) (interface{}, error)) (interface{}, error) {
+	if len(parts) == 0 {
+		return fn(x)
+	}
+
+	switch key := parts[0].(type) {
+	case string:
+		child := x.Get(key)
+		if child.err != nil {
+			return nil, child.err
+		}
+		v, err := update_in(child, parts[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c := copy_object(x.MustObject(), 0)
+		c[key] = v
+		return c, nil
+
+	default:
+		idx := key.(int)
+		child := x.GetIndex(idx)
+		if child.err != nil {
+			return nil, child.err
+		}
+		v, err := update_in(child, parts[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c := copy_array(x.MustArray(), 0)
+		c[idx] = v
+		return c, nil
+	}
+}
+
+// relative_path returns the parts of path below x. It fails when path is
+// not inside x.
+func (x Value) relative_path(path Selector) ([]interface{}, error) {
+	base, err := selector_path(x.selector)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := selector_path(path)
+	if err != nil {
+		return nil, err
+	}
+
+	inside := len(parts) >= len(base)
+	for i := 0; inside && i < len(base); i++ {
+		inside = parts[i] == base[i]
+	}
+	if !inside {
+		return nil, &selector_error{fmt.Errorf("xjson: %s is not inside %s", path, x.Selector()), x.Selector()}
+	}
+	return parts[len(base):], nil
+}
+
+func (x Value) update_error(err error) Value {
+	if _, ok := err.(*selector_error); !ok {
+		err = &selector_error{err, x.Selector()}
+	}
+	return Value{nil, err, x.Selector()}
+}
+
+func copy_object(o map[string]interface{}, extra int) map[string]interface{} {
+	c := make(map[string]interface{}, len(o)+extra)
+	for k, v := range o {
+		c[k] = v
+	}
+	return c
+}
+
+func copy_array(a []interface{}, extra int) []interface{} {
+	c := make([]interface{}, len(a), len(a)+extra)
+	copy(c, a)
+	return c
+}
+
+// deep_copy copies all containers in x, so the copy can be handed out
+// without exposing the internals of a Value.
+func deep_copy(x interface{}) interface{} {
+	switch y := x.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(y))
+		for k, v := range y {
+			c[k] = deep_copy(v)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(y))
+		for i, v := range y {
+			c[i] = deep_copy(v)
+		}
+		return c
+	default:
+		return x
+	}
+}
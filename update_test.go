@@ -0,0 +1,120 @@
+package xjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValue_update(t *testing.T) {
+	const doc = `{"a":{"k":1},"b":[1,2],"n":3}`
+
+	var tests = []struct {
+		name string
+		fn   func(x Value) Value
+		out  string
+		err  string
+	}{
+		{"set", func(x Value) Value { return x.Set(path("a", "k"), 2) }, `{"a":{"k":2},"b":[1,2],"n":3}`, ``},
+		{"set new key", func(x Value) Value { return x.Set(path("a", "l"), true) }, `{"a":{"k":1,"l":true},"b":[1,2],"n":3}`, ``},
+		{"set root", func(x Value) Value { return x.Set(path(), "x") }, `"x"`, ``},
+		{"set element", func(x Value) Value { return x.Set(path("b", 1), nil) }, `{"a":{"k":1},"b":[1,null],"n":3}`, ``},
+		{"delete", func(x Value) Value { return x.Delete(path("b", 0)) }, `{"a":{"k":1},"b":[2],"n":3}`, ``},
+		{"insert", func(x Value) Value { return x.Insert(path("b"), 0, 0) }, `{"a":{"k":1},"b":[0,1,2],"n":3}`, ``},
+		{"append", func(x Value) Value { return x.Append(path("b"), 3) }, `{"a":{"k":1},"b":[1,2,3],"n":3}`, ``},
+
+		{"missing key", func(x Value) Value { return x.Set(path("x", "y"), 1) }, ``,
+			`xjson: key not found (at: $root.x, line 1 col 1)`},
+		{"delete missing key", func(x Value) Value { return x.Delete(path("a", "x")) }, ``,
+			`xjson: key not found (at: $root.a.x, line 1 col 6)`},
+		{"set out of range", func(x Value) Value { return x.Set(path("b", 2), 1) }, ``,
+			`xjson: index out of range (at: $root.b[2], line 1 col 18)`},
+		{"delete out of range", func(x Value) Value { return x.Delete(path("b", -1)) }, ``,
+			`xjson: index out of range (at: $root.b[-1], line 1 col 18)`},
+		{"insert out of range", func(x Value) Value { return x.Insert(path("b"), 3, 1) }, ``,
+			`xjson: index out of range (at: $root.b[3], line 1 col 18)`},
+		{"index into object", func(x Value) Value { return x.Set(path("a", 0), 1) }, ``,
+			`xjson: map[string]interface {} is not a json array (at: $root.a, line 1 col 6)`},
+		{"key into number", func(x Value) Value { return x.Set(path("n", "k", "l"), 1) }, ``,
+			`xjson: int64 is not a json object (at: $root.n, line 1 col 28)`},
+		{"append to object", func(x Value) Value { return x.Append(path("a"), 1) }, ``,
+			`xjson: map[string]interface {} is not a json array (at: $root.a, line 1 col 6)`},
+		{"delete root", func(x Value) Value { return x.Delete(path()) }, ``,
+			`xjson: cannot delete the root value (at: $root, line 1 col 1)`},
+	}
+
+	for _, test := range tests {
+		var (
+			x = Parse([]byte(doc))
+			v = test.fn(x)
+		)
+
+		if b, _ := json.Marshal(&x); string(b) != doc {
+			t.Errorf("%s: modified the original value: %s", test.name, b)
+		}
+
+		_, err := v.Interface()
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if b, _ := json.Marshal(&v); string(b) != test.out {
+			t.Errorf("%s:\n  expected %s\n  got      %s", test.name, test.out, b)
+		}
+	}
+}
+
+func TestValue_update_inside(t *testing.T) {
+	doc := Parse([]byte(`{"a":{"b":1,"a":{"b":2}}}`))
+	sub := doc.Get("a")
+
+	var tests = []struct {
+		name string
+		v    Value
+		out  string
+	}{
+		{"selector of a child", sub.Set(sub.Get("b").Selector(), 5), `{"a":{"b":2},"b":5}`},
+		{"selector from another lookup", sub.Set(doc.Get("a").Get("b").Selector(), 5), `{"a":{"b":2},"b":5}`},
+		{"path selector", sub.Set(path("a", "a", "b"), 6), `{"a":{"b":6},"b":1}`},
+		{"parsed selector", sub.Set(must_parse_selector("$root.a.a.b"), 6), `{"a":{"b":6},"b":1}`},
+		{"delete a child", sub.Delete(doc.GetPath("a", "a").Selector()), `{"b":1}`},
+		{"append to a child", sub.Set(path("a", "a"), []interface{}{}).Append(path("a"), 1), `{"a":[1],"b":1}`},
+	}
+
+	for _, test := range tests {
+		if _, err := test.v.Interface(); err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+			continue
+		}
+		if b, _ := json.Marshal(&test.v); string(b) != test.out {
+			t.Errorf("%s:\n  expected %s\n  got      %s", test.name, test.out, b)
+		}
+	}
+
+	_, err := sub.Set(path("b"), 1).Interface()
+	if err == nil || err.Error() != `xjson: $root.b is not inside $root.a (at: $root.a, line 1 col 6)` {
+		t.Errorf("expected paths outside of the value to be rejected, got %v", err)
+	}
+	_, err = sub.Set(path("a", "x", "y"), 1).Interface()
+	if err == nil || err.Error() != `xjson: key not found (at: $root.a.x, line 1 col 6)` {
+		t.Errorf("expected errors to be located in the whole document, got %v", err)
+	}
+}
+
+// path builds the selector GetPath(parts...) would return from the root.
+func path(parts ...interface{}) Selector {
+	return path_selector(parts)
+}
+
+func must_parse_selector(s string) Selector {
+	sel, err := ParseSelector(s)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
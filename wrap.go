@@ -1,19 +1,124 @@
 package xjson
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
+// Unwrapper controls how a Value is stored into a Go value. The zero
+// Unwrapper collects every error it encounters.
+type Unwrapper struct {
+	// FailFast stops unwrapping at the first error.
+	FailFast bool
+}
+
+// UnwrapError reports a json value that could not be stored in a Go value.
+type UnwrapError struct {
+	Selector Selector
+	Kind     Kind
+	Type     reflect.Type
+	reason   string
+}
+
+func (e *UnwrapError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Selector, e.reason)
+}
+
+// UnwrapErrors lists all errors found while unwrapping a Value.
+type UnwrapErrors []error
+
+func (e UnwrapErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("xjson: %d errors while unwrapping:", len(e)))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n\t")
+}
+
 func (x Value) Unwrap(i interface{}) error {
-	return x.UnwrapValue(reflect.ValueOf(i))
+	var u Unwrapper
+	return u.Unwrap(x, i)
 }
 
 func (x Value) UnwrapValue(v reflect.Value) error {
+	var u Unwrapper
+	return u.UnwrapValue(x, v)
+}
+
+func (u *Unwrapper) Unwrap(x Value, i interface{}) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("xjson: Unwrap() expects a non-nil pointer (got %T)", i)
+	}
+	return u.UnwrapValue(x, v)
+}
+
+// UnwrapValue stores x in v. When FailFast is not set all errors are
+// returned as UnwrapErrors, otherwise the first error is returned as is.
+func (u *Unwrapper) UnwrapValue(x Value, v reflect.Value) error {
+	if !v.CanSet() && (v.Kind() != reflect.Ptr || v.IsNil()) {
+		return fmt.Errorf("xjson: cannot unwrap into unaddressable %s", v.Type())
+	}
+
+	s := unwrap_state{fail_fast: u.FailFast}
+	s.unwrap(x, v)
+
+	if len(s.errs) == 0 {
+		return nil
+	}
+	if s.fail_fast {
+		return s.errs[0]
+	}
+	return s.errs
+}
+
+type unwrap_state struct {
+	fail_fast bool
+	errs      UnwrapErrors
+}
+
+func (s *unwrap_state) done() bool {
+	return s.fail_fast && len(s.errs) > 0
+}
+
+func (s *unwrap_state) fail(err error) {
+	s.errs = append(s.errs, err)
+}
+
+func (s *unwrap_state) mismatch(x Value, t reflect.Type) {
+	k := x.Kind()
+	s.fail(&UnwrapError{x.Selector(), k, t,
+		fmt.Sprintf("json %s is not assignable to %s", strings.ToLower(k.String()), t)})
+}
+
+func (s *unwrap_state) overflow(x Value, t reflect.Type) {
+	if r, err := number_rat(x.inner); err == nil && !r.IsInt() {
+		s.fail(&UnwrapError{x.Selector(), Number, t,
+			fmt.Sprintf("json number %v is not assignable to %s", x.inner, t)})
+		return
+	}
+	s.fail(&UnwrapError{x.Selector(), Number, t,
+		fmt.Sprintf("json number %v overflows %s", x.inner, t)})
+}
+
+// number_type is stored as the literal of a json number.
+var number_type = reflect.TypeOf(json.Number(""))
+
+func (s *unwrap_state) unwrap(x Value, v reflect.Value) {
 	if x.Kind() == Error {
-		return x.err
+		s.fail(x.err)
+		return
 	}
 	if x.Kind() == Null {
-		return nil
+		return
 	}
 
 	// drill down on the pointers
@@ -24,42 +129,187 @@ func (x Value) UnwrapValue(v reflect.Value) error {
 		v = v.Elem()
 	}
 
+	if v.Kind() == reflect.Interface {
+		if v.NumMethod() != 0 {
+			s.mismatch(x, v.Type())
+			return
+		}
+		// the caller may modify what it gets, x must not change
+		v.Set(reflect.ValueOf(deep_copy(x.inner)))
+		return
+	}
+
 	switch x.Kind() {
 	case Bool:
+		if v.Kind() != reflect.Bool {
+			s.mismatch(x, v.Type())
+			return
+		}
 		v.SetBool(x.MustBool())
+
 	case Number:
 		switch v.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			v.SetInt(x.MustInt64())
-		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			v.SetUint(x.MustUint64())
+			i, err := x.Int64()
+			if err != nil || v.OverflowInt(i) {
+				s.overflow(x, v.Type())
+				return
+			}
+			v.SetInt(i)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			i, err := x.Uint64()
+			if err != nil || v.OverflowUint(i) {
+				s.overflow(x, v.Type())
+				return
+			}
+			v.SetUint(i)
 		case reflect.Float32, reflect.Float64:
-			v.SetFloat(x.MustFloat64())
+			f, err := x.Float64()
+			if err != nil || v.OverflowFloat(f) {
+				s.overflow(x, v.Type())
+				return
+			}
+			v.SetFloat(f)
+		case reflect.String:
+			if v.Type() != number_type {
+				s.mismatch(x, v.Type())
+				return
+			}
+			v.SetString(string(x.MustNumber()))
 		default:
-			v.Set(reflect.ValueOf(x.inner))
+			s.mismatch(x, v.Type())
 		}
+
 	case String:
+		if v.Kind() != reflect.String {
+			s.mismatch(x, v.Type())
+			return
+		}
 		v.SetString(x.MustString())
+
 	case Array:
 		l := x.Len()
-		s := reflect.MakeSlice(v.Type(), l, l)
 
-		for i := 0; i < l; i++ {
-			x.GetIndex(i).UnwrapValue(s.Index(i))
+		switch v.Kind() {
+		case reflect.Slice:
+			a := reflect.MakeSlice(v.Type(), l, l)
+			for i := 0; i < l && !s.done(); i++ {
+				s.unwrap(x.GetIndex(i), a.Index(i))
+			}
+			v.Set(a)
+		case reflect.Array:
+			for i := 0; i < v.Len() && !s.done(); i++ {
+				if i < l {
+					s.unwrap(x.GetIndex(i), v.Index(i))
+				} else {
+					v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+				}
+			}
+		default:
+			s.mismatch(x, v.Type())
 		}
 
-		v.Set(s)
 	case Object:
-		m := reflect.MakeMap(v.Type())
+		switch {
+		case v.Kind() == reflect.Struct:
+			s.unwrap_struct(x, v)
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			var (
+				m    = reflect.MakeMap(v.Type())
+				keys = sorted_keys(x.MustObject())
+			)
+
+			for _, key := range keys {
+				if s.done() {
+					break
+				}
+				e := reflect.New(m.Type().Elem())
+				s.unwrap(x.Get(key), e)
+				m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), e.Elem())
+			}
 
-		for key := range x.MustObject() {
-			e := reflect.New(m.Type().Elem())
-			x.Get(key).UnwrapValue(e)
-			m.SetMapIndex(reflect.ValueOf(key), e.Elem())
+			v.Set(m)
+		default:
+			s.mismatch(x, v.Type())
 		}
+	}
+}
+
+func (s *unwrap_state) unwrap_struct(x Value, v reflect.Value) {
+	var (
+		fields   = cached_fields(v.Type())
+		members  = x.MustObject()
+		assigned = make([]bool, len(fields))
+	)
 
-		v.Set(m)
+	// exact matches take precedence over case-insensitive ones
+	for i, f := range fields {
+		if s.done() {
+			return
+		}
+		if _, found := members[f.name]; found {
+			assigned[i] = true
+			s.unwrap_field(x.Get(f.name), v, f)
+		}
 	}
 
-	return nil
+	for _, key := range sorted_keys(members) {
+		exact := false
+		for _, f := range fields {
+			if f.name == key {
+				exact = true
+				break
+			}
+		}
+		if exact {
+			continue
+		}
+
+		for i, f := range fields {
+			if s.done() {
+				return
+			}
+			if !assigned[i] && strings.EqualFold(f.name, key) {
+				assigned[i] = true
+				s.unwrap_field(x.Get(key), v, f)
+				break
+			}
+		}
+	}
+}
+
+func (s *unwrap_state) unwrap_field(x Value, v reflect.Value, f field) {
+	fv, ok := field_by_index(v, f.index)
+	if !ok {
+		return
+	}
+
+	if f.quoted {
+		// the ",string" option wraps the actual value in a json string
+		switch x.Kind() {
+		case Null:
+			return
+		case String:
+			y := Parse([]byte(x.MustString()))
+			if k := y.Kind(); k != Error && k != Array && k != Object {
+				s.unwrap(Value{y.inner, nil, x.selector}, fv)
+				return
+			}
+		}
+		s.fail(&UnwrapError{x.Selector(), x.Kind(), fv.Type(),
+			fmt.Sprintf("invalid use of ,string struct tag, trying to unwrap %s into %s",
+				strings.ToLower(x.Kind().String()), fv.Type())})
+		return
+	}
+
+	s.unwrap(x, fv)
+}
+
+func sorted_keys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,156 @@
+package xjson
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestValue_Unwrap_nonFinite(t *testing.T) {
+	var tests = []struct {
+		in  float64
+		err string
+	}{
+		{math.NaN(), `$root: json number NaN overflows int`},
+		{math.Inf(1), `$root: json number +Inf overflows int`},
+		{math.Inf(-1), `$root: json number -Inf overflows int`},
+	}
+
+	for _, test := range tests {
+		var i int
+		err := ValueOf(test.in).Unwrap(&i)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%v: expected error %q, got %v", test.in, test.err, err)
+		}
+	}
+}
+
+func TestValue_Unwrap_copies(t *testing.T) {
+	doc := Parse([]byte(`{"a":{"b":"x"},"l":[[1]]}`))
+
+	var i interface{}
+	if err := doc.Unwrap(&i); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	i.(map[string]interface{})["a"].(map[string]interface{})["b"] = "mutated"
+
+	var m map[string]interface{}
+	if err := doc.Unwrap(&m); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	m["a"].(map[string]interface{})["b"] = "mutated"
+	m["l"].([]interface{})[0].([]interface{})[0] = "mutated"
+
+	if out := describe_value(doc); out != `{"a":{"b":"x"},"l":[[1]]}` {
+		t.Errorf("unwrapping exposed the internals of the value: %s", out)
+	}
+}
+
+type unwrap_inner struct {
+	A int
+	B int `json:"b"`
+}
+
+type unwrap_other struct {
+	A int
+	C int
+}
+
+type unwrap_tagged struct {
+	C int `json:"C"`
+}
+
+type unwrap_deep struct {
+	unwrap_inner
+}
+
+type Unwrap_exported struct {
+	E int
+}
+
+type unwrap_fields struct {
+	// A conflicts at depth 1 and is dropped, B is only in unwrap_inner
+	unwrap_inner
+	unwrap_other
+
+	// C is tagged in unwrap_tagged, which beats the untagged C above
+	unwrap_tagged
+
+	// shallower fields win over deeper ones
+	D int
+	*unwrap_deep
+
+	// embedded pointers to unexported structs are skipped while nil
+	*Unwrap_exported
+
+	Skipped int    `json:"-"`
+	Dash    int    `json:"-,"`
+	Quoted  int    `json:",string"`
+	Text    string `json:"text,string"`
+	Name    string `json:"name"`
+	NAME    string
+}
+
+func TestValue_Unwrap_fields(t *testing.T) {
+	var tests = []struct {
+		in string
+	}{
+		{`{"A":1,"b":2,"C":3,"D":4,"E":5}`},
+		{`{"Skipped":1,"-":2,"Dash":3}`},
+		{`{"Quoted":"12","text":"\"quoted\""}`},
+		{`{"name":"exact","NAME":"upper"}`},
+		{`{"NAME":"upper","Name":"title"}`},
+		{`{"nAmE":"folded"}`},
+		{`{"c":7,"d":8,"B":9}`},
+	}
+
+	for _, test := range tests {
+		var x, y unwrap_fields
+
+		err := Parse([]byte(test.in)).Unwrap(&x)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.in, err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(test.in), &y); err != nil {
+			t.Errorf("%s: encoding/json failed: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(x, y) {
+			t.Errorf("%s:\n  encoding/json %+v\n  got           %+v", test.in, y, x)
+		}
+	}
+
+	var q struct {
+		N int `json:"n,string"`
+	}
+	err := Parse([]byte(`{"n":3}`)).Unwrap(&q)
+	if err == nil || err.Error() != `$root.n: invalid use of ,string struct tag, trying to unwrap number into int` {
+		t.Errorf("expected ,string to require a string, got %v", err)
+	}
+}
+
+func TestValue_Unwrap_unexportedPointer(t *testing.T) {
+	type inner struct{ A int }
+	type outer struct {
+		*inner
+		B int
+	}
+
+	var x outer
+	if err := Parse([]byte(`{"A":1,"B":2}`)).Unwrap(&x); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if x.inner != nil || x.B != 2 {
+		t.Errorf("expected the nil embedded pointer to be skipped, got %+v", x)
+	}
+
+	x = outer{inner: &inner{}}
+	if err := Parse([]byte(`{"A":1,"B":2}`)).Unwrap(&x); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if x.inner.A != 1 {
+		t.Errorf("expected the existing embedded struct to be filled, got %+v", x.inner)
+	}
+}
@@ -1,8 +1,15 @@
 package xjson
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fd/xjson/internal/jsontext"
 )
 
 type Value struct {
@@ -25,16 +32,83 @@ const (
 	Error
 )
 
+func (k Kind) String() string {
+	return kind_strings[k]
+}
+
+var kind_strings = map[Kind]string{
+	Null:   "Null",
+	Bool:   "Bool",
+	Number: "Number",
+	String: "String",
+	Array:  "Array",
+	Object: "Object",
+
+	Error: "Error",
+}
+
+// Parse parses a single json document. Anything but whitespace after the
+// document is an error.
 func Parse(data []byte) Value {
-	var (
-		v interface{}
-	)
-	if err := json.Unmarshal(data, &v); err != nil {
-		v = err
+	return parse(data, Position{0, 1, 1})
+}
+
+// parse parses data as if it was found at start in a larger source.
+func parse(data []byte, start Position) Value {
+	d := &Decoder{s: new_scanner_at(bytes.NewReader(data), start)}
+
+	v, sp, err := d.read_document()
+	if err == nil {
+		err = d.expect_end()
+	}
+	if err != nil {
+		return ValueOf(err)
+	}
+	return Value{v, nil, &root_selector{v, sp}}
+}
+
+// ParseMany parses a sequence of json documents. It accepts both RFC 7464
+// json text sequences, where every document is preceded by a record
+// separator (0x1E), and documents that simply follow each other. In a text
+// sequence a malformed document yields an Error value and parsing resumes at
+// the next record separator; otherwise parsing stops at the first error.
+func ParseMany(data []byte) []Value {
+	var values []Value
+
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == record_separator {
+		// positions are carried from record to record, so that every byte
+		// is only looked at once
+		pos := position_at(data, len(data)-len(trimmed)+1)
+		for _, record := range bytes.Split(trimmed[1:], []byte{record_separator}) {
+			if len(bytes.TrimSpace(record)) > 0 {
+				values = append(values, parse(record, pos))
+			}
+			pos = advance(pos, record)
+			pos = advance(pos, []byte{record_separator})
+		}
+		return values
+	}
+
+	d := NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Next()
+		if err == io.EOF {
+			return values
+		}
+		if err != nil {
+			return append(values, ValueOf(err))
+		}
+
+		v, sp, err := d.read_value(tok)
+		if err != nil {
+			return append(values, ValueOf(err))
+		}
+		values = append(values, Value{v, nil, &root_selector{v, sp}})
 	}
-	return ValueOf(v)
 }
 
+const record_separator = 0x1E
+
 func ValueOf(x interface{}) Value {
 	var (
 		v Value
@@ -44,7 +118,7 @@ func ValueOf(x interface{}) Value {
 	case nil:
 		v.inner = x
 	case error:
-		v.inner = x
+		v.err = i
 	case bool:
 		v.inner = x
 
@@ -65,31 +139,104 @@ func ValueOf(x interface{}) Value {
 	case uint32:
 		v.inner = int64(i)
 	case uint64:
-		v.inner = int64(i)
+		if i > math.MaxInt64 {
+			v.inner = json.Number(strconv.FormatUint(i, 10))
+		} else {
+			v.inner = int64(i)
+		}
 
 	case float32:
 		v.inner = float64(i)
 	case float64:
 		v.inner = x
+	case json.Number:
+		s := new_scanner(strings.NewReader(string(i)))
+		n, err := s.scan_number()
+		if err != nil || s.chr != -1 {
+			return ValueOf(type_conflict_error(x, "json number", &root_selector{}))
+		}
+		v.inner = n
 
 	case string:
 		v.inner = x
-	case []interface{}:
-		v.inner = x
-	case map[string]interface{}:
-		v.inner = x
+	case []interface{}, map[string]interface{}:
+		y, _, err := normalize(x)
+		if err != nil {
+			return ValueOf(err)
+		}
+		v.inner = y
+	case Value:
+		v.inner, v.err = i.inner, i.err
 	default:
 		return ValueOf(type_conflict_error(x, "json type", &root_selector{}))
 	}
 
-	v.selector = &root_selector{v.inner}
+	v.selector = &root_selector{v.inner, nil}
 
 	return v
 }
 
+// normalize converts the elements of json containers to the types used by
+// Value. Containers are only copied when one of their elements changes.
+func normalize(x interface{}) (interface{}, bool, error) {
+	switch i := x.(type) {
+	case nil, bool, int64, float64, string:
+		return x, false, nil
+
+	case []interface{}:
+		var out []interface{}
+		for idx, e := range i {
+			y, changed, err := normalize(e)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && out == nil {
+				out = make([]interface{}, len(i))
+				copy(out, i)
+			}
+			if out != nil {
+				out[idx] = y
+			}
+		}
+		if out == nil {
+			return x, false, nil
+		}
+		return out, true, nil
+
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, e := range i {
+			y, changed, err := normalize(e)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && out == nil {
+				out = make(map[string]interface{}, len(i))
+				for k, v := range i {
+					out[k] = v
+				}
+			}
+			if out != nil {
+				out[key] = y
+			}
+		}
+		if out == nil {
+			return x, false, nil
+		}
+		return out, true, nil
+
+	default:
+		v := ValueOf(x)
+		if v.err != nil {
+			return nil, false, v.err
+		}
+		return v.inner, true, nil
+	}
+}
+
 func (x Value) Selector() Selector {
 	if x.selector == nil {
-		return &root_selector{nil}
+		return &root_selector{nil, nil}
 	}
 	return x.selector
 }
@@ -105,7 +252,7 @@ func (x Value) Kind() Kind {
 		return Null
 	case bool:
 		return Bool
-	case int64, float64:
+	case int64, float64, json.Number:
 		return Number
 	case string:
 		return String
@@ -136,34 +283,19 @@ func (x Value) Bool() (bool, error) {
 	return false, type_conflict_error(i, "json bool", x.selector)
 }
 
+// Int64 returns x as an int64. Unlike Float64 it fails when x has a
+// fraction or doesn't fit.
 func (x Value) Int64() (int64, error) {
-	i, err := x.Interface()
-	if err != nil {
-		return 0, err
-	}
-	if v, ok := i.(int64); ok {
-		return v, nil
-	}
-	if v, ok := i.(float64); ok {
-		return int64(v), nil
-	}
-	return 0, type_conflict_error(x.inner, "json number", x.selector)
+	return x.int_value(math.MinInt64, math.MaxInt64, "int64")
 }
 
+// Uint64 returns x as a uint64. It fails when x has a fraction, is negative
+// or doesn't fit.
 func (x Value) Uint64() (uint64, error) {
-	i, err := x.Interface()
-	if err != nil {
-		return 0, err
-	}
-	if v, ok := i.(int64); ok {
-		return uint64(v), nil
-	}
-	if v, ok := i.(float64); ok {
-		return uint64(v), nil
-	}
-	return 0, type_conflict_error(x.inner, "json number", x.selector)
+	return x.uint_value(math.MaxUint64, "uint64")
 }
 
+// Float64 returns the nearest float64 to x. It fails when x doesn't fit.
 func (x Value) Float64() (float64, error) {
 	i, err := x.Interface()
 	if err != nil {
@@ -175,6 +307,13 @@ func (x Value) Float64() (float64, error) {
 	if v, ok := i.(int64); ok {
 		return float64(v), nil
 	}
+	if v, ok := i.(json.Number); ok {
+		f, err := v.Float64()
+		if err != nil {
+			return 0, x.out_of_range("float64")
+		}
+		return f, nil
+	}
 	return 0, type_conflict_error(x.inner, "json number", x.selector)
 }
 
@@ -251,8 +390,8 @@ func (x Value) GetIndex(idx int) Value {
 	if err != nil {
 		return Value{nil, err, &index_selector{err, idx, x.selector}}
 	}
-	if idx >= len(a) {
-		err = fmt.Errorf("xjson: index out of range")
+	if idx < 0 || idx >= len(a) {
+		err = err_index_out_of_range
 		sel := &index_selector{err, idx, x.selector}
 		err = &selector_error{err, sel}
 		return Value{nil, err, sel}
@@ -268,7 +407,7 @@ func (x Value) Get(key string) Value {
 	}
 	v, found := o[key]
 	if !found {
-		err = fmt.Errorf("xjson: key not found")
+		err = err_key_not_found
 		sel := &key_selector{err, key, x.selector}
 		err = &selector_error{err, sel}
 		return Value{nil, err, sel}
@@ -290,6 +429,16 @@ func (x Value) GetPath(parts ...interface{}) Value {
 	return x
 }
 
+// At applies the path described by sel to x, treating x as the root.
+func (x Value) At(sel Selector) Value {
+	parts, err := selector_path(sel)
+	if err != nil {
+		err = &selector_error{err, x.Selector()}
+		return Value{nil, err, x.Selector()}
+	}
+	return x.GetPath(parts...)
+}
+
 func (x *Value) MarshalJSON() ([]byte, error) {
 	i, err := x.Interface()
 	if err != nil {
@@ -300,13 +449,26 @@ func (x *Value) MarshalJSON() ([]byte, error) {
 }
 
 func (x *Value) UnmarshalJSON(b []byte) error {
-	v := Parse(b)
+	// b is a fragment of a larger document, so its positions would be
+	// misleading.
+	v := ValueOf(Parse(b))
 	*x = v
 	return nil
 }
 
 func type_conflict_error(x interface{}, expected_type string, sel Selector) error {
-	return &selector_error{fmt.Errorf("xjson: %T is not a %s", x, expected_type), sel}
+	return &selector_error{&type_conflict{x, expected_type}, sel}
+}
+
+// type_conflict keeps the offending value, so that IsMissing can tell
+// lookups in null apart.
+type type_conflict struct {
+	value         interface{}
+	expected_type string
+}
+
+func (e *type_conflict) Error() string {
+	return fmt.Sprintf("xjson: %T is not a %s", e.value, e.expected_type)
 }
 
 type selector_error struct {
@@ -314,7 +476,23 @@ type selector_error struct {
 	selector Selector
 }
 
+func init() {
+	jsontext.PathError = func(err error, path []interface{}) error {
+		return &selector_error{err, path_selector(path)}
+	}
+	jsontext.NewSyntaxError = func(src []byte, offset int, expected string, path []interface{}) error {
+		var sel Selector
+		if path != nil {
+			sel = path_selector(path)
+		}
+		return new_syntax_error(src, offset, expected, sel)
+	}
+}
+
 func (s *selector_error) Error() string {
+	if sp := find_span(s.selector, false); sp != nil {
+		return fmt.Sprintf("%s (at: %s, %s)", s.err, s.selector, sp.start)
+	}
 	return fmt.Sprintf("%s (at: %s)", s.err, s.selector)
 }
 